@@ -110,18 +110,51 @@ func (tc *TripClient) GetCurrentAlerts(ctx context.Context) ([]Alert, error) {
 	return parsed.Infos.Alerts, nil
 }
 
-func (tc *TripClient) TripPlan(ctx context.Context, origin string, destination string) ([]Journey, error) {
-	now := time.Now().Format("1504")
+// TripOptions changes when journeys are planned for, the zero value
+// departs now
+type TripOptions struct {
+	At       time.Time
+	ArriveBy bool // At is the latest arrival rather than the earliest departure
+}
+
+// Upcoming drops journeys that leave before they were asked for: before At
+// if it's set, otherwise before now. Journeys arriving by At all leave
+// before it, so they're only checked against now when At is in the future
+func (o TripOptions) Upcoming(journeys []Journey, now time.Time) []Journey {
+	switch {
+	case o.At.IsZero():
+		return UpcomingJourneys(journeys, now)
+	case o.ArriveBy && o.At.After(now):
+		return UpcomingJourneys(journeys, now)
+	case o.ArriveBy:
+		return journeys
+	}
+	// leaving right at At counts
+	return UpcomingJourneys(journeys, o.At.Add(-time.Second))
+}
+
+func (tc *TripClient) TripPlan(ctx context.Context, origin string, destination string, opts TripOptions) ([]Journey, error) {
+	at := opts.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	depArr := "dep" // trips departing at the given time
+	if opts.ArriveBy {
+		depArr = "arr"
+	}
+
 	params := tripQuery{
 		OutputFormat:      "rapidJSON",
 		CoordOutputFormat: "EPSG:4326",
-		DepArrMacro:       "dep", // trips departing now
+		DepArrMacro:       depArr,
 		TypeOrigin:        "any",
 		OriginID:          origin,
 		TypeDestination:   "any",
 		DestinationID:     destination,
 		ExcludedMeans:     "11", // exclude school buses
-		Time:              now,
+		Date:              at.Format("20060102"),
+		Time:              at.Format("1504"),
 	}
 
 	data, err := tc.fetchData(ctx, "/trip", params)
@@ -130,7 +163,41 @@ func (tc *TripClient) TripPlan(ctx context.Context, origin string, destination s
 	}
 
 	var parsed tripResponse
-	json.Unmarshal(data, &parsed)
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("reading trip plan: %w", err)
+	}
+
+	return parsed.Journeys, nil
+}
+
+// Departures lists the services leaving a stop from at onwards,
+// a zero at means now
+func (tc *TripClient) Departures(ctx context.Context, stopID string, at time.Time) ([]StopEvent, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	params := departureQuery{
+		OutputFormat:      "rapidJSON",
+		CoordOutputFormat: "EPSG:4326",
+		Mode:              "direct",
+		TypeDM:            "stop",
+		NameDM:            stopID,
+		DepArrMacro:       "dep",
+		Date:              at.Format("20060102"),
+		Time:              at.Format("1504"),
+		TfNSWDM:           "true",
+	}
+
+	data, err := tc.fetchData(ctx, "/departure_mon", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed departureResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("reading departures: %w", err)
+	}
 
-	return parsed.Journeys, err
+	return parsed.StopEvents, nil
 }
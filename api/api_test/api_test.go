@@ -1,9 +1,57 @@
 package api_test
 
 import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/state"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func TestMain(m *testing.M) {}
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+// openTestDatabase opens app.sqlite, skipping tests that need it when it
+// hasn't been built, see makedatabase.sh
+func openTestDatabase(t *testing.T) *sql.DB {
+	if _, err := os.Stat(state.DatabasePath); err != nil {
+		t.Skipf("no database at %s, run makedatabase.sh", state.DatabasePath)
+	}
+	db, err := api.OpenDatabase(state.DatabasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCheckSchema(t *testing.T) {
+	verify := func(schema string, ok bool) {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "app.sqlite"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if _, err := db.Exec(schema); err != nil {
+			t.Fatal(err)
+		}
+
+		err = api.CheckSchema(db)
+		if ok && err != nil {
+			t.Errorf("%s: %v", schema, err)
+		}
+		if !ok && !errors.Is(err, api.ErrOldDatabase) {
+			t.Errorf("%s: expected ErrOldDatabase, got %v", schema, err)
+		}
+	}
+
+	verify(`create table stop (id text, name text, lat real, lon real)`, false)
+	verify(`create table other (id text)`, false)
+	verify(`create table stop (id text, name text, lat real, lon real, parent_id text, modes integer, wheelchair_boarding integer)`, true)
+}
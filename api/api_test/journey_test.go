@@ -0,0 +1,35 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+)
+
+func journeyLeaving(at time.Time) api.Journey {
+	var leg api.Leg
+	leg.Origin.DepartureTimeEstimated = at.UTC().Format(time.RFC3339)
+	return api.Journey{Legs: []api.Leg{leg}}
+}
+
+func TestTripOptionsUpcoming(t *testing.T) {
+	now := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	journeys := []api.Journey{
+		journeyLeaving(now.Add(-2 * time.Hour)), // 7:00
+		journeyLeaving(now.Add(-time.Hour)),     // 8:00
+		journeyLeaving(now.Add(time.Hour)),      // 10:00
+	}
+
+	verify := func(name string, opts api.TripOptions, want int) {
+		if got := len(opts.Upcoming(journeys, now)); got != want {
+			t.Errorf("%s: expected %d journeys, got %d", name, want, got)
+		}
+	}
+
+	verify("leaving now", api.TripOptions{}, 1)
+	verify("leaving at 7:00, asked at 9:00", api.TripOptions{At: now.Add(-2 * time.Hour)}, 3)
+	verify("leaving at 7:30", api.TripOptions{At: now.Add(-90 * time.Minute)}, 2)
+	verify("arriving by 8:30, asked at 9:00", api.TripOptions{At: now.Add(-30 * time.Minute), ArriveBy: true}, 3)
+	verify("arriving by 11:00", api.TripOptions{At: now.Add(2 * time.Hour), ArriveBy: true}, 1)
+}
@@ -1,14 +1,9 @@
 package api_test
 
 import (
-	"database/sql"
-	"log"
 	"testing"
 
 	"github.com/isobelmcrae/trip/api"
-	"github.com/isobelmcrae/trip/state"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 func TestSearchStopSanitiseSearch(t *testing.T) {
@@ -24,13 +19,7 @@ func TestSearchStopSanitiseSearch(t *testing.T) {
 }
 
 func TestSearchStop(t *testing.T) {
-	db, err := sql.Open("sqlite3", state.DatabasePath)
-    if err != nil {
-        log.Fatal(err)
-    }
-	defer db.Close()
-
-	tc := api.NewClient(db)
+	tc := api.NewClient(openTestDatabase(t))
 
 	verify := func(search string, ID ...string) {
 		stops := tc.FindStop(search)
@@ -62,4 +51,34 @@ func TestSearchStop(t *testing.T) {
 	verify("central",
 		"200060", // Central Station
 	)
+
+	for _, stop := range tc.FindStopByMode("central", api.ModeTrain) {
+		if !stop.Modes.Has(api.ModeTrain) {
+			t.Errorf("stop %s is not served by trains", stop.ID)
+		}
+	}
+
+	for _, platform := range tc.FindPlatforms("200060") {
+		if platform.ParentID != "200060" {
+			t.Errorf("platform %s belongs to %s, not Central", platform.ID, platform.ParentID)
+		}
+	}
+}
+
+func TestModeForRouteType(t *testing.T) {
+	verify := func(routeType int, mode api.Mode) {
+		got := api.ModeForRouteType(routeType)
+		if got != mode {
+			t.Errorf("route type %d: expected %s, got %s", routeType, mode, got)
+		}
+	}
+
+	verify(2, api.ModeTrain)
+	verify(401, api.ModeMetro)
+	verify(900, api.ModeLightRail)
+	verify(700, api.ModeBus)
+	verify(714, api.ModeBus) // rail replacement
+	verify(204, api.ModeCoach)
+	verify(4, api.ModeFerry)
+	verify(1500, api.ModeAny)
 }
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrOldDatabase is returned for an app.sqlite missing columns this
+// version needs, e.g. one built before stops knew their parent station
+var ErrOldDatabase = errors.New("app.sqlite is missing or out of date, rebuild it with ./makedatabase.sh (which runs api_load_data)")
+
+// columns the stop table needs, added since the first app.sqlite
+var stopSchemaColumns = []string{"parent_id", "modes", "wheelchair_boarding"}
+
+// CheckSchema makes sure db was built by the current api_load_data
+func CheckSchema(db *sql.DB) error {
+	rows, err := db.Query(`select name from pragma_table_info('stop')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	have := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		have[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range stopSchemaColumns {
+		if !have[column] {
+			return fmt.Errorf("%w: stop has no %s column", ErrOldDatabase, column)
+		}
+	}
+	return nil
+}
+
+// OpenDatabase opens the app.sqlite at path, checking it's up to date
+func OpenDatabase(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
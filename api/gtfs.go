@@ -18,6 +18,12 @@ type GtfsTrip struct {
 	ShapeID string
 }
 
+type GtfsRoute struct {
+	RouteID   string
+	ShortName string
+	Type      int
+}
+
 type GtfsShapePoint struct {
 	ShapeID  string
 	Lat      float64
@@ -148,3 +154,51 @@ func (tc *TripClient) GetJourneyLeg(originStopID, destStopID string) ([][2]float
 
 	return path, nil
 }
+
+// LegPath is the [lat, lon] path a leg follows, built from the shape between
+// each pair of consecutive stops. Walks and stops without shape data fall
+// back to the path given by the trip planner, then to straight lines
+func (tc *TripClient) LegPath(l Leg) [][2]float64 {
+	var path [][2]float64
+
+	for i := 1; i < len(l.StopSequence); i++ {
+		points, err := tc.GetJourneyLeg(l.StopSequence[i-1].ID, l.StopSequence[i].ID)
+		if err != nil || len(points) == 0 {
+			prev, stop := l.StopSequence[i-1].Coord, l.StopSequence[i].Coord
+			if len(prev) < 2 || len(stop) < 2 {
+				continue
+			}
+			points = [][2]float64{{prev[0], prev[1]}, {stop[0], stop[1]}}
+		}
+		path = append(path, points...)
+	}
+	if len(path) > 0 {
+		return path
+	}
+
+	for _, coord := range l.Coords {
+		if len(coord) >= 2 {
+			path = append(path, [2]float64{coord[0], coord[1]})
+		}
+	}
+	if len(path) > 0 {
+		return path
+	}
+
+	if len(l.Origin.Coord) >= 2 && len(l.Destination.Coord) >= 2 {
+		path = [][2]float64{
+			{l.Origin.Coord[0], l.Origin.Coord[1]},
+			{l.Destination.Coord[0], l.Destination.Coord[1]},
+		}
+	}
+	return path
+}
+
+// JourneyPaths is LegPath for every leg of j
+func (tc *TripClient) JourneyPaths(j Journey) [][][2]float64 {
+	paths := make([][][2]float64, len(j.Legs))
+	for i, l := range j.Legs {
+		paths[i] = tc.LegPath(l)
+	}
+	return paths
+}
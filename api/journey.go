@@ -0,0 +1,168 @@
+package api
+
+import "time"
+
+// UpcomingJourneys drops journeys whose first leg has already departed
+func UpcomingJourneys(journeys []Journey, now time.Time) []Journey {
+	var upcoming []Journey
+	for _, journey := range journeys {
+		if len(journey.Legs) > 0 {
+			departure, err := time.Parse(time.RFC3339, journey.Legs[0].Origin.DepartureTimeEstimated)
+			if err == nil && departure.After(now) {
+				upcoming = append(upcoming, journey)
+			}
+		}
+	}
+	return upcoming
+}
+
+// Mode is how a leg is travelled, ModeAny for walking
+func (l Leg) Mode() Mode {
+	if l.Transportation == nil {
+		return ModeAny
+	}
+	return ModeForProductClass(l.Transportation.Product.Class)
+}
+
+// IsWalk reports whether the leg is on foot
+func (l Leg) IsWalk() bool {
+	return l.Transportation == nil || l.Transportation.DisassembledName == ""
+}
+
+// IsCancelled reports whether the leg's service has been cancelled
+func (l Leg) IsCancelled() bool {
+	for _, status := range l.RealtimeStatus {
+		if status == "TRIP_CANCELLED" || status == "CANCELLED" {
+			return true
+		}
+	}
+	return false
+}
+
+// DepartureDelay is how much later than timetabled the leg leaves,
+// negative when early, and false without a realtime estimate
+func (l Leg) DepartureDelay() (time.Duration, bool) {
+	return legDelay(l, l.Origin.DepartureTimePlanned, l.Origin.DepartureTimeEstimated)
+}
+
+// ArrivalDelay is how much later than timetabled the leg arrives
+func (l Leg) ArrivalDelay() (time.Duration, bool) {
+	return legDelay(l, l.Destination.ArrivalTimePlanned, l.Destination.ArrivalTimeEstimated)
+}
+
+func legDelay(l Leg, planned string, estimated string) (time.Duration, bool) {
+	if !l.IsRealtimeControlled {
+		return 0, false
+	}
+	p, err := time.Parse(time.RFC3339, planned)
+	if err != nil {
+		return 0, false
+	}
+	e, err := time.Parse(time.RFC3339, estimated)
+	if err != nil {
+		return 0, false
+	}
+	return e.Sub(p), true
+}
+
+// FirstService is the first leg travelled on a service rather than on foot
+func (j Journey) FirstService() (Leg, bool) {
+	for _, l := range j.Legs {
+		if !l.IsWalk() {
+			return l, true
+		}
+	}
+	return Leg{}, false
+}
+
+// LeaveAt is when to set off to make the journey: the first service's
+// estimated departure, less any walking to get to it
+func (j Journey) LeaveAt() (time.Time, bool) {
+	var walking time.Duration
+	for _, l := range j.Legs {
+		if !l.IsWalk() {
+			departure, err := parseEventTime(l.Origin.DepartureTimeEstimated, l.Origin.DepartureTimePlanned)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return departure.Add(-walking), true
+		}
+		walking += time.Duration(l.Duration) * time.Second
+	}
+
+	// walking the whole way, so leave when the walk starts
+	if len(j.Legs) == 0 {
+		return time.Time{}, false
+	}
+	departure, err := parseEventTime(j.Legs[0].Origin.DepartureTimeEstimated, j.Legs[0].Origin.DepartureTimePlanned)
+	return departure, err == nil
+}
+
+// parseEventTime reads the estimated time when there is one, otherwise
+// the planned time
+func parseEventTime(estimated string, planned string) (time.Time, error) {
+	if estimated != "" {
+		return time.Parse(time.RFC3339, estimated)
+	}
+	return time.Parse(time.RFC3339, planned)
+}
+
+// Departure is when the journey's first leg leaves
+func (j Journey) Departure() (time.Time, bool) {
+	if len(j.Legs) == 0 {
+		return time.Time{}, false
+	}
+	t, err := parseEventTime(j.Legs[0].Origin.DepartureTimeEstimated, j.Legs[0].Origin.DepartureTimePlanned)
+	return t, err == nil
+}
+
+// Arrival is when the journey's last leg arrives
+func (j Journey) Arrival() (time.Time, bool) {
+	if len(j.Legs) == 0 {
+		return time.Time{}, false
+	}
+	last := j.Legs[len(j.Legs)-1]
+	t, err := parseEventTime(last.Destination.ArrivalTimeEstimated, last.Destination.ArrivalTimePlanned)
+	return t, err == nil
+}
+
+// Changes is how many times the journey changes service
+func (j Journey) Changes() int {
+	services := 0
+	for _, l := range j.Legs {
+		if !l.IsWalk() {
+			services++
+		}
+	}
+	return max(services-1, 0)
+}
+
+// WalkingTime is the time spent on foot
+func (j Journey) WalkingTime() time.Duration {
+	var walking time.Duration
+	for _, l := range j.Legs {
+		if l.IsWalk() {
+			walking += time.Duration(l.Duration) * time.Second
+		}
+	}
+	return walking
+}
+
+// Modes are the kinds of transport the journey uses
+func (j Journey) Modes() Mode {
+	var modes Mode
+	for _, l := range j.Legs {
+		modes |= l.Mode()
+	}
+	return modes
+}
+
+// AdultFare is the journey's adult Opal fare, when the API gives one
+func (j Journey) AdultFare() (float64, bool) {
+	for _, t := range j.Fare.Tickets {
+		if t.Person == "ADULT" {
+			return t.PriceBrutto, true
+		}
+	}
+	return 0, false
+}
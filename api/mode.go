@@ -0,0 +1,111 @@
+package api
+
+// Mode is a bitmask of the kinds of transport serving a stop
+type Mode int
+
+const (
+	ModeTrain Mode = 1 << iota
+	ModeMetro
+	ModeLightRail
+	ModeBus
+	ModeCoach
+	ModeFerry
+
+	// matches any stop, used when filtering searches
+	ModeAny Mode = 0
+)
+
+// in display order
+var AllModes = []Mode{ModeTrain, ModeMetro, ModeLightRail, ModeBus, ModeCoach, ModeFerry}
+
+// GTFS wheelchair_boarding values
+const (
+	WheelchairUnknown      = 0
+	WheelchairAccessible   = 1
+	WheelchairInaccessible = 2
+)
+
+// ModeForRouteType maps a GTFS route_type, including the extended
+// types used by TfNSW, onto a Mode
+// https://developers.google.com/transit/gtfs/reference/extended-route-types
+func ModeForRouteType(routeType int) Mode {
+	switch {
+	case routeType == 0, routeType >= 900 && routeType < 1000:
+		return ModeLightRail
+	case routeType == 1, routeType >= 400 && routeType < 500:
+		return ModeMetro
+	case routeType == 2, routeType >= 100 && routeType < 200:
+		return ModeTrain
+	case routeType == 3, routeType >= 700 && routeType < 800:
+		return ModeBus
+	case routeType >= 200 && routeType < 300:
+		return ModeCoach
+	case routeType == 4, routeType >= 1000 && routeType < 1100, routeType == 1200:
+		return ModeFerry
+	}
+	return ModeAny
+}
+
+// ModeForProductClass maps the product class the trip planner gives each
+// service onto a Mode, walks and anything unknown are ModeAny
+func ModeForProductClass(class int) Mode {
+	switch class {
+	case 1:
+		return ModeTrain
+	case 2:
+		return ModeMetro
+	case 4:
+		return ModeLightRail
+	case 5, 11: // including school buses
+		return ModeBus
+	case 7:
+		return ModeCoach
+	case 9:
+		return ModeFerry
+	}
+	return ModeAny
+}
+
+// Has reports whether any of the modes in other are set
+func (m Mode) Has(other Mode) bool {
+	return m&other != 0
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeAny:
+		return "all"
+	case ModeTrain:
+		return "trains"
+	case ModeMetro:
+		return "metro"
+	case ModeLightRail:
+		return "light rail"
+	case ModeBus:
+		return "buses"
+	case ModeCoach:
+		return "coaches"
+	case ModeFerry:
+		return "ferries"
+	}
+	return "mixed"
+}
+
+// Name is the singular name of a single mode, e.g. "train"
+func (m Mode) Name() string {
+	switch m {
+	case ModeTrain:
+		return "train"
+	case ModeMetro:
+		return "metro"
+	case ModeLightRail:
+		return "light rail"
+	case ModeBus:
+		return "bus"
+	case ModeCoach:
+		return "coach"
+	case ModeFerry:
+		return "ferry"
+	}
+	return "walk"
+}
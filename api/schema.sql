@@ -3,7 +3,10 @@ create table if not exists "stop" (
 	"id" text not null primary key,
 	"name" text not null,
 	"lat" real not null,
-	"lon" real not null
+	"lon" real not null,
+	"parent_id" text,
+	"modes" integer not null default 0,
+	"wheelchair_boarding" integer not null default 0
 );
 
 create virtual table if not exists "stop_fts" using fts5(
@@ -26,6 +29,12 @@ CREATE TABLE IF NOT EXISTS "trips" (
     "shape_id" TEXT
 );
 
+CREATE TABLE IF NOT EXISTS "routes" (
+    "route_id" TEXT NOT NULL PRIMARY KEY,
+    "route_short_name" TEXT NOT NULL,
+    "route_type" INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS "shapes" (
     "shape_id" TEXT NOT NULL,
     "shape_pt_lat" REAL NOT NULL,
@@ -35,6 +44,7 @@ CREATE TABLE IF NOT EXISTS "shapes" (
     PRIMARY KEY (shape_id, shape_pt_sequence)
 );
 
+CREATE INDEX IF NOT EXISTS "idx_stop_parent_id" ON "stop" ("parent_id");
 CREATE INDEX IF NOT EXISTS "idx_stop_times_trip_id" ON "stop_times" ("trip_id");
 CREATE INDEX IF NOT EXISTS "idx_stop_times_stop_id" ON "stop_times" ("stop_id");
 CREATE INDEX IF NOT EXISTS "idx_trips_shape_id" ON "trips" ("shape_id");
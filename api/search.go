@@ -1,7 +1,9 @@
 package api
 
 import (
+	"database/sql"
 	"regexp"
+	"strings"
 
 	"github.com/charmbracelet/log"
 )
@@ -26,50 +28,94 @@ func SanitiseSeach(search string) string {
 }
 
 type StopSearchResult struct {
-	ID string
-	Name   string
-	Lat    float64
-	Lon    float64
+	ID                 string
+	Name               string
+	Lat                float64
+	Lon                float64
+	ParentID           string // empty for stations and standalone stops
+	Modes              Mode
+	WheelchairBoarding int
+}
+
+const stopColumns = `s.id, s.name, s.lat, s.lon, coalesce(s.parent_id, ''), s.modes, s.wheelchair_boarding`
+
+func scanStops(rows *sql.Rows) []StopSearchResult {
+	results := make([]StopSearchResult, 0, SearchStopMaxResults)
+
+	defer rows.Close()
+	for rows.Next() {
+		var stop StopSearchResult
+
+		err := rows.Scan(&stop.ID, &stop.Name, &stop.Lat, &stop.Lon, &stop.ParentID, &stop.Modes, &stop.WheelchairBoarding)
+		if err != nil {
+			log.Fatalf("cannot scan rows: %v", err)
+		}
+
+		results = append(results, stop)
+	}
+
+	return results
 }
 
 // this should never fail
 func (tc *TripClient) FindStop(search string) []StopSearchResult {
+	return tc.FindStopByMode(search, ModeAny)
+}
+
+// FindStopByMode searches stations and standalone stops, keeping only those
+// served by at least one of modes. ModeAny disables the filter
+func (tc *TripClient) FindStopByMode(search string, modes Mode) []StopSearchResult {
 	// assumed to finish quickly, context unnecessary
 	rows, err := tc.db.Query(`
-		select s.id, s.name, s.lat, s.lon
+		select `+stopColumns+`
 		from stop_fts as fts
 			join stop as s on fts.id = s.id
-		where fts.name match ?
+		where fts.name match ? and (? = 0 or s.modes & ? != 0)
 		order by rank
 		limit ?
-	`, SanitiseSeach(search), SearchStopMaxResults)
+	`, SanitiseSeach(search), modes, modes, SearchStopMaxResults)
 	if err != nil {
 		log.Fatalf("cannot perform search: %v", err)
 	}
 
-	results := make([]StopSearchResult, 0, SearchStopMaxResults)
+	return scanStops(rows)
+}
 
-	defer rows.Close()
-	for rows.Next() {
-		var id string
-		var name string
-		var lat float64
-		var lon float64
+// FindPlatforms returns the child platforms of a station
+func (tc *TripClient) FindPlatforms(stationID string) []StopSearchResult {
+	rows, err := tc.db.Query(`
+		select `+stopColumns+`
+		from stop as s
+		where s.parent_id = ?
+		order by s.name
+	`, stationID)
+	if err != nil {
+		log.Fatalf("cannot find platforms: %v", err)
+	}
 
-		err = rows.Scan(&id, &name, &lat, &lon)
-		if err != nil {
-			log.Fatalf("cannot scan rows: %v", err)
-		}
+	return scanStops(rows)
+}
 
-		results = append(results, StopSearchResult{
-			ID: id,
-			Name: name,
-			Lat: lat,
-			Lon: lon,
-		})
+// ResolveStop narrows a search down to the stop the user most likely meant.
+// A single result is unambiguous, as is a single exact match on the name,
+// otherwise every candidate is returned
+func (tc *TripClient) ResolveStop(search string) []StopSearchResult {
+	stops := tc.FindStop(search)
+	if len(stops) <= 1 {
+		return stops
 	}
 
-	return results
+	var exact []StopSearchResult
+	for _, stop := range stops {
+		if strings.EqualFold(stop.Name, search) || stop.ID == search {
+			exact = append(exact, stop)
+		}
+	}
+	if len(exact) == 1 {
+		return exact
+	}
+
+	return stops
 }
 
 func (tc *TripClient) FindStopFirstOrPanic(search string) StopSearchResult {
@@ -75,9 +75,35 @@ type tripQuery struct {
 	TypeDestination   string `url:"type_destination"`
 	DestinationID     string `url:"name_destination"`
 	ExcludedMeans     string `url:"excludedMeans"`
+	Date              string `url:"itdDate"`
 	Time              string `url:"itdTime"`
 }
 
+type departureQuery struct {
+	OutputFormat      string `url:"outputFormat"`
+	CoordOutputFormat string `url:"coordOutputFormat"`
+	Mode              string `url:"mode"`
+	TypeDM            string `url:"type_dm"`
+	NameDM            string `url:"name_dm"`
+	DepArrMacro       string `url:"depArrMacro"`
+	Date              string `url:"itdDate"`
+	Time              string `url:"itdTime"`
+	TfNSWDM           string `url:"TfNSWDM"`
+}
+
+type departureResponse struct {
+	StopEvents []StopEvent `json:"stopEvents"`
+}
+
+// a single service calling at a stop
+type StopEvent struct {
+	Location               Location        `json:"location"`
+	DepartureTimePlanned   string          `json:"departureTimePlanned"`
+	DepartureTimeEstimated string          `json:"departureTimeEstimated"`
+	Transportation         *Transportation `json:"transportation"`
+	IsRealtimeControlled   bool            `json:"isRealtimeControlled"`
+}
+
 type tripResponse struct {
 	Journeys []Journey `json:"journeys"`
 }
@@ -86,6 +112,18 @@ type Journey struct {
 	IsAdditional bool  `json:"isAdditional"` // indicates it's not the "preferred" journey
 	Legs         []Leg `json:"legs"`
 	Rating       int   `json:"rating"`
+	Fare         Fare  `json:"fare"`
+}
+
+type Fare struct {
+	Tickets []Ticket `json:"tickets"`
+}
+
+// a fare for one kind of passenger, for the whole journey
+type Ticket struct {
+	Name        string  `json:"name"`
+	Person      string  `json:"person"` // e.g. ADULT, CHILD, SENIOR
+	PriceBrutto float64 `json:"priceBrutto"`
 }
 
 type Leg struct {
@@ -95,10 +133,39 @@ type Leg struct {
 	Distance             int             `json:"distance"`
 	Transportation       *Transportation `json:"transportation"`
 	StopSequence         []JourneyStop   `json:"stopSequence"`
+	Coords               [][]float64     `json:"coords"` // the path as [lat, lon] pairs, walks especially
 	IsRealtimeControlled bool            `json:"isRealtimeControlled"`
+	RealtimeStatus       []string        `json:"realtimeStatus"` // e.g. MONITORED, TRIP_CANCELLED
 }
 
 type Location struct {
+	ID                     string             `json:"id"`
+	Name                   string             `json:"name"`
+	DisassembledName       string             `json:"disassembledName"`
+	ArrivalTimePlanned     string             `json:"arrivalTimePlanned"`
+	ArrivalTimeEstimated   string             `json:"arrivalTimeEstimated"`
+	DepartureTimePlanned   string             `json:"departureTimePlanned"`
+	DepartureTimeEstimated string             `json:"departureTimeEstimated"`
+	Coord                  []float64          `json:"coord"`
+	Type                   string             `json:"type"`
+	Properties             LocationProperties `json:"properties"`
+}
+
+type LocationProperties struct {
+	Platform     string `json:"platform"`
+	PlatformName string `json:"platformName"`
+}
+
+// Platform is the stand or platform a leg leaves from or arrives at,
+// empty when the API doesn't say
+func (l Location) Platform() string {
+	if l.Properties.PlatformName != "" {
+		return l.Properties.PlatformName
+	}
+	return l.Properties.Platform
+}
+
+type JourneyStop struct {
 	ID                     string    `json:"id"`
 	Name                   string    `json:"name"`
 	DisassembledName       string    `json:"disassembledName"`
@@ -110,16 +177,6 @@ type Location struct {
 	Type                   string    `json:"type"`
 }
 
-type JourneyStop struct {
-	ID                   string    `json:"id"`
-	Name                 string    `json:"name"`
-	DisassembledName     string    `json:"disassembledName"`
-	ArrivalTimePlanned   string    `json:"arrivalTimePlanned"`
-	DepartureTimePlanned string    `json:"departureTimePlanned"`
-	Coord                []float64 `json:"coord"`
-	Type                 string    `json:"type"`
-}
-
 type Transportation struct {
 	ID               string      `json:"id"`
 	Name             string      `json:"name"`
@@ -128,6 +185,12 @@ type Transportation struct {
 	DisassembledName string      `json:"disassembledName"`
 	Destination      Destination `json:"destination"`
 	IconID           int         `json:"iconId"`
+	Product          Product     `json:"product"`
+}
+
+type Product struct {
+	Class int    `json:"class"`
+	Name  string `json:"name"`
 }
 
 type Destination struct {
@@ -58,18 +58,33 @@ func parseCSV[T any](filePath string, parser func([]string, map[string]int) (T,
 	return results, nil
 }
 
+// optional reads a column GTFS doesn't require, empty when the feed leaves
+// it out, which is the default for each of them
+func optional(r []string, c map[string]int, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(r) {
+		return ""
+	}
+	return r[i]
+}
+
 func main() {
 	// go run api_load_schema ./app.sqlite ./stops.txt
 	databasePath := os.Args[1]
 	gtfsPath := os.Args[2]
 
 	stops, _ := parseCSV(gtfsPath + "/stops.txt", func(r []string, c map[string]int) (api.StopSearchResult, error) {
-		if r[c["parent_station"]] != "" {
-			return api.StopSearchResult{}, fmt.Errorf("is child station")
+		// keep stations and their platforms, entrances and pathway nodes are no use to us
+		if locationType, _ := strconv.Atoi(optional(r, c, "location_type")); locationType > 1 {
+			return api.StopSearchResult{}, fmt.Errorf("is not a stop or station")
 		}
 		lat, _ := strconv.ParseFloat(r[c["stop_lat"]], 64)
 		lon, _ := strconv.ParseFloat(r[c["stop_lon"]], 64)
-		return api.StopSearchResult{ID: r[c["stop_id"]], Name: r[c["stop_name"]], Lat: lat, Lon: lon}, nil
+		wheelchair, _ := strconv.Atoi(optional(r, c, "wheelchair_boarding"))
+		return api.StopSearchResult{
+			ID: r[c["stop_id"]], Name: r[c["stop_name"]], Lat: lat, Lon: lon,
+			ParentID: optional(r, c, "parent_station"), WheelchairBoarding: wheelchair,
+		}, nil
 	})
 
 	stopTimes, _ := parseCSV(gtfsPath + "/stop_times.txt", func(r []string, c map[string]int) (api.GtfsStopTime, error) {
@@ -82,6 +97,11 @@ func main() {
 		return api.GtfsTrip{TripID: r[c["trip_id"]], RouteID: r[c["route_id"]], ShapeID: r[c["shape_id"]]}, nil
 	})
 
+	routes, _ := parseCSV(gtfsPath + "/routes.txt", func(r []string, c map[string]int) (api.GtfsRoute, error) {
+		routeType, err := strconv.Atoi(r[c["route_type"]])
+		return api.GtfsRoute{RouteID: r[c["route_id"]], ShortName: r[c["route_short_name"]], Type: routeType}, err
+	})
+
 	shapePoints, _ := parseCSV(gtfsPath + "/shapes.txt", func(r []string, c map[string]int) (api.GtfsShapePoint, error) {
 		lat, _ := strconv.ParseFloat(r[c["shape_pt_lat"]], 64)
 		lon, _ := strconv.ParseFloat(r[c["shape_pt_lon"]], 64)
@@ -90,6 +110,8 @@ func main() {
 		return api.GtfsShapePoint{ShapeID: r[c["shape_id"]], Lat: lat, Lon: lon, Sequence: seq, DistanceTraveled: dist}, nil
 	})
 
+	tagStops(stops, stopTimes, trips, routes)
+
 	db, err := sql.Open("sqlite3", databasePath)
 	if err != nil {
 		log.Fatal(err)
@@ -103,12 +125,26 @@ func main() {
 	}
 
 	log.Println("Inserting stops...")
-	stmt, err := tx.Prepare("INSERT INTO stop(id, name, lat, lon) VALUES(?, ?, ?, ?)")
+	stmt, err := tx.Prepare("INSERT INTO stop(id, name, lat, lon, parent_id, modes, wheelchair_boarding) VALUES(?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		log.Fatal(err)
 	}
 	for _, s := range stops {
-		stmt.Exec(s.ID, s.Name, s.Lat, s.Lon)
+		var parentID any
+		if s.ParentID != "" {
+			parentID = s.ParentID
+		}
+		stmt.Exec(s.ID, s.Name, s.Lat, s.Lon, parentID, s.Modes, s.WheelchairBoarding)
+	}
+	stmt.Close()
+
+	log.Println("Inserting routes...")
+	stmt, err = tx.Prepare("INSERT INTO routes(route_id, route_short_name, route_type) VALUES(?, ?, ?)")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range routes {
+		stmt.Exec(r.RouteID, r.ShortName, r.Type)
 	}
 	stmt.Close()
 
@@ -150,7 +186,8 @@ func main() {
 
 	// Rebuild FTS index
 	log.Println("Rebuilding FTS index...")
-	db.Exec(`INSERT INTO stop_fts (id, name) SELECT id, name FROM stop;`)
+	// platforms are reached through their station, only index the top level
+	db.Exec(`INSERT INTO stop_fts (id, name) SELECT id, name FROM stop WHERE parent_id IS NULL;`)
 	db.Exec(`INSERT INTO stop_fts(stop_fts) VALUES('optimize');`)
 
 	log.Println("VACUUM + ANALYZE...")
@@ -158,3 +195,46 @@ func main() {
 	db.Exec(`ANALYZE;`)
 	log.Println("FTS index complete.")
 }
+
+// tagStops works out which modes serve each stop from the routes of the trips
+// calling there, rolls platform modes up into their parent station, and lets
+// platforms inherit the station's wheelchair_boarding when they have none
+func tagStops(stops []api.StopSearchResult, stopTimes []api.GtfsStopTime, trips []api.GtfsTrip, routes []api.GtfsRoute) {
+	routeModes := make(map[string]api.Mode, len(routes))
+	for _, r := range routes {
+		routeModes[r.RouteID] = api.ModeForRouteType(r.Type)
+	}
+
+	tripModes := make(map[string]api.Mode, len(trips))
+	for _, t := range trips {
+		tripModes[t.TripID] = routeModes[t.RouteID]
+	}
+
+	stopModes := make(map[string]api.Mode)
+	for _, st := range stopTimes {
+		stopModes[st.StopID] |= tripModes[st.TripID]
+	}
+
+	// platforms first, so stations can collect their modes
+	stationModes := make(map[string]api.Mode)
+	for i := range stops {
+		stops[i].Modes = stopModes[stops[i].ID]
+		if stops[i].ParentID != "" {
+			stationModes[stops[i].ParentID] |= stops[i].Modes
+		}
+	}
+
+	stationWheelchair := make(map[string]int)
+	for i := range stops {
+		if stops[i].ParentID == "" {
+			stops[i].Modes |= stationModes[stops[i].ID]
+			stationWheelchair[stops[i].ID] = stops[i].WheelchairBoarding
+		}
+	}
+
+	for i := range stops {
+		if stops[i].ParentID != "" && stops[i].WheelchairBoarding == api.WheelchairUnknown {
+			stops[i].WheelchairBoarding = stationWheelchair[stops[i].ParentID]
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/isobelmcrae/trip/rendermaps"
+)
+
+// RunCache implements `trip cache stats` and `trip cache purge`, for the
+// map tiles kept on disk. It returns the process exit code
+func RunCache(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: trip cache stats|purge")
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "stats":
+		stats, err := rendermaps.TileCacheStats()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+		fmt.Printf("%s\n", stats.Dir)
+		fmt.Printf("%d tiles, %s of %s\n", stats.Files, formatBytes(stats.Bytes), formatBytes(stats.MaxBytes))
+		fmt.Printf("tiles unused for %d days are removed\n", int(stats.MaxAge.Hours()/24))
+		if stats.Files > 0 {
+			fmt.Printf("least recently used %s, most recently %s\n",
+				stats.Oldest.Format(time.DateTime), stats.Newest.Format(time.DateTime))
+		}
+		return ExitOK
+
+	case "purge":
+		removed, freed, err := rendermaps.PurgeTileCache()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+		fmt.Printf("removed %d tiles, %s\n", removed, formatBytes(freed))
+		return ExitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown cache command %q, expected stats or purge\n", args[0])
+	return ExitUsage
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,106 @@
+// Package cli holds trip's non-interactive subcommands, e.g. `trip plan`
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/state"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// exit codes, so scripts can tell what went wrong
+const (
+	ExitOK          = 0
+	ExitError       = 1 // anything not covered below
+	ExitUsage       = 2
+	ExitStop        = 3 // a stop couldn't be found, or was ambiguous
+	ExitAuth        = 4 // TFNSW_KEY is missing or wrong
+	ExitUnavailable = 5 // the TfNSW API is down or erroring
+)
+
+var (
+	ErrStopNotFound  = errors.New("no stop found")
+	ErrStopAmbiguous = errors.New("more than one stop found")
+)
+
+// ExitCode maps an error onto one of the exit codes above
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrStopNotFound), errors.Is(err, ErrStopAmbiguous):
+		return ExitStop
+	case errors.Is(err, api.ErrServerNotAuthenticated):
+		return ExitAuth
+	case errors.Is(err, api.ErrServerUnavailable), errors.Is(err, api.ErrServerInternalError):
+		return ExitUnavailable
+	}
+	return ExitError
+}
+
+// TripOptions reads the --at and --arrive-by flags
+func TripOptions(at string, arriveBy string) (api.TripOptions, error) {
+	var opts api.TripOptions
+	var err error
+
+	switch {
+	case at != "" && arriveBy != "":
+		return opts, errors.New("--at and --arrive-by can't be used together")
+	case at != "":
+		opts.At, err = ParseWhen(at, time.Now())
+	case arriveBy != "":
+		opts.At, err = ParseWhen(arriveBy, time.Now())
+		opts.ArriveBy = true
+	}
+
+	return opts, err
+}
+
+// ParseWhen reads a clock time today, or a full date and time
+func ParseWhen(s string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04", "3:04pm", "3pm"} {
+		if t, err := time.ParseInLocation(layout, strings.ToLower(s), time.Local); err == nil {
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't understand the time %q, try 15:04 or 2006-01-02 15:04", s)
+}
+
+func openClient() (*api.TripClient, error) {
+	db, err := api.OpenDatabase(state.DatabasePath)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewClient(db), nil
+}
+
+// resolveStop turns a favourite's name or a search into a single stop,
+// there's nobody to ask so ambiguity is an error
+func resolveStop(client *api.TripClient, favourites *state.Favourites, query string) (api.StopSearchResult, error) {
+	if fav, ok := favourites.FindStop(query); ok {
+		return api.StopSearchResult{ID: fav.StopID, Name: fav.StopName}, nil
+	}
+
+	stops := client.ResolveStop(query)
+	switch len(stops) {
+	case 0:
+		return api.StopSearchResult{}, fmt.Errorf("%w for %q", ErrStopNotFound, query)
+	case 1:
+		return stops[0], nil
+	}
+
+	var candidates strings.Builder
+	for _, stop := range stops {
+		fmt.Fprintf(&candidates, "\n  %s\t%s", stop.ID, stop.Name)
+	}
+	return api.StopSearchResult{}, fmt.Errorf("%w for %q, use a stop ID:%s", ErrStopAmbiguous, query, candidates.String())
+}
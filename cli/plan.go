@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/export"
+	"github.com/isobelmcrae/trip/format"
+	"github.com/isobelmcrae/trip/state"
+)
+
+// RunPlan implements `trip plan`, printing journeys instead of starting the TUI.
+// It returns the process exit code
+func RunPlan(args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	from := fs.String("from", "", "origin stop name, ID or favourite")
+	to := fs.String("to", "", "destination stop name, ID or favourite")
+	at := fs.String("at", "", "depart at this time (15:04 or 2006-01-02 15:04)")
+	arriveBy := fs.String("arrive-by", "", "arrive by this time (15:04 or 2006-01-02 15:04)")
+	asJSON := fs.Bool("json", false, "print the journeys as JSON")
+	icsPath := fs.String("ics", "", "also write the first journey to this iCalendar file")
+	icsTrip := fs.Bool("ics-trip", false, "with --ics, one event for the whole trip rather than one per leg")
+	geojsonPath := fs.String("geojson", "", "also write the first journey's path to this GeoJSON file")
+	gpxPath := fs.String("gpx", "", "also write the first journey's path to this GPX file")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if *from == "" || *to == "" {
+		fmt.Fprintln(os.Stderr, "trip plan needs both --from and --to")
+		fs.Usage()
+		return ExitUsage
+	}
+
+	opts, err := TripOptions(*at, *arriveBy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitUsage
+	}
+
+	client, err := openClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitError
+	}
+
+	journeys, err := plan(client, *from, *to, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitCode(err)
+	}
+
+	exports := []struct {
+		path  string
+		write func(io.Writer, api.Journey) error
+	}{
+		{*icsPath, func(w io.Writer, j api.Journey) error {
+			return export.WriteICS(w, j, export.ICSOptions{WholeTrip: *icsTrip})
+		}},
+		{*geojsonPath, func(w io.Writer, j api.Journey) error {
+			return export.WriteGeoJSON(w, j, client.JourneyPaths(j))
+		}},
+		{*gpxPath, func(w io.Writer, j api.Journey) error {
+			return export.WriteGPX(w, j, client.JourneyPaths(j))
+		}},
+	}
+	for _, e := range exports {
+		if e.path == "" {
+			continue
+		}
+		if err := writeExportFile(e.path, journeys, e.write); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+	}
+
+	if *asJSON {
+		err = printJourneysJSON(os.Stdout, journeys)
+	} else {
+		err = printJourneysText(os.Stdout, journeys)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitError
+	}
+
+	return ExitOK
+}
+
+// plan resolves both stops and fetches the journeys yet to depart
+func plan(client *api.TripClient, from string, to string, opts api.TripOptions) ([]api.Journey, error) {
+	favourites, err := state.OpenFavourites(state.ConfigDir())
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err := resolveStop(client, favourites, from)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := resolveStop(client, favourites, to)
+	if err != nil {
+		return nil, err
+	}
+
+	journeys, err := client.TripPlan(context.Background(), origin.ID, destination.ID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Upcoming(journeys, time.Now()), nil
+}
+
+// writeExportFile exports the first, soonest, journey
+func writeExportFile(path string, journeys []api.Journey, write func(io.Writer, api.Journey) error) error {
+	if len(journeys) == 0 {
+		return errors.New("no journeys to export")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, journeys[0]); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printJourneysText(w io.Writer, journeys []api.Journey) error {
+	if len(journeys) == 0 {
+		_, err := fmt.Fprintln(w, "No routes found.")
+		return err
+	}
+
+	for i, journey := range journeys {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if _, err := fmt.Fprint(w, format.JourneyText(time.Local, journey)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printJourneysJSON(w io.Writer, journeys []api.Journey) error {
+	// always an array, even when empty
+	if journeys == nil {
+		journeys = []api.Journey{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(journeys)
+}
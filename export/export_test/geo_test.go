@@ -0,0 +1,72 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/isobelmcrae/trip/export"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func testPaths() [][][2]float64 {
+	return [][][2]float64{
+		{{-33.884, 151.207}, {-33.887, 151.230}, {-33.891, 151.248}},
+		{{-33.891, 151.248}, {-33.892, 151.250}},
+	}
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteGeoJSON(&buf, testJourney(), testPaths()); err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := geojson.UnmarshalFeatureCollection(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("expected a feature per leg, got %d", len(fc.Features))
+	}
+
+	line, ok := fc.Features[0].Geometry.(orb.LineString)
+	if !ok || len(line) != 3 {
+		t.Fatalf("expected a 3 point LineString, got %v", fc.Features[0].Geometry)
+	}
+	// [lon, lat] order
+	if line[0] != (orb.Point{151.207, -33.884}) {
+		t.Errorf("coordinates not in lon, lat order: %v", line[0])
+	}
+	if mode := fc.Features[1].Properties.MustString("mode"); mode != "walk" {
+		t.Errorf("expected walk, got %s", mode)
+	}
+}
+
+func TestWriteGPX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteGPX(&buf, testJourney(), testPaths()); err != nil {
+		t.Fatal(err)
+	}
+
+	var gpx struct {
+		Waypoints []struct{} `xml:"wpt"`
+		Tracks    []struct {
+			Points []struct {
+				Lat float64 `xml:"lat,attr"`
+			} `xml:"trkseg>trkpt"`
+		} `xml:"trk"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &gpx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gpx.Tracks) != 2 || len(gpx.Tracks[0].Points) != 3 {
+		t.Errorf("expected 2 tracks with the leg paths, got %+v", gpx.Tracks)
+	}
+	// origin, interchange and destination
+	if len(gpx.Waypoints) != 3 {
+		t.Errorf("expected 3 waypoints, got %d", len(gpx.Waypoints))
+	}
+}
@@ -0,0 +1,65 @@
+package export_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/export"
+)
+
+func testJourney() api.Journey {
+	return api.Journey{Legs: []api.Leg{
+		{
+			Origin:         api.Location{Name: "Central Station, Platform 24", DisassembledName: "Central", DepartureTimeEstimated: "2025-08-01T22:12:00Z", Coord: []float64{-33.884, 151.207}},
+			Destination:    api.Location{Name: "Bondi Junction Station", DisassembledName: "Bondi Junction", ArrivalTimeEstimated: "2025-08-01T22:24:00Z", Coord: []float64{-33.891, 151.248}},
+			Transportation: &api.Transportation{DisassembledName: "T4", Destination: api.Destination{Name: "Bondi Junction"}},
+		},
+		{
+			Origin:         api.Location{Name: "Bondi Junction Station", DisassembledName: "Bondi Junction", DepartureTimeEstimated: "2025-08-01T22:24:00Z", Coord: []float64{-33.891, 151.248}},
+			Destination:    api.Location{Name: "Oxford St, Bondi Junction", DisassembledName: "Oxford St", ArrivalTimeEstimated: "2025-08-01T22:30:00Z", Coord: []float64{-33.892, 151.250}},
+			Transportation: &api.Transportation{},
+		},
+	}}
+}
+
+func TestWriteICS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteICS(&buf, testJourney(), export.ICSOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	ics := buf.String()
+
+	// the walk isn't an event
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+	if !strings.Contains(ics, "DTSTART:20250801T221200Z\r\n") {
+		t.Errorf("missing start time in\n%s", ics)
+	}
+	// commas are escaped
+	if !strings.Contains(ics, `LOCATION:Central Station\, Platform 24`) {
+		t.Errorf("location not escaped in\n%s", ics)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestWriteICSWholeTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteICS(&buf, testJourney(), export.ICSOptions{WholeTrip: true}); err != nil {
+		t.Fatal(err)
+	}
+	ics := buf.String()
+
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+	if !strings.Contains(ics, "DTEND:20250801T223000Z\r\n") {
+		t.Errorf("trip should end on arrival of the last leg in\n%s", ics)
+	}
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/styles"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// paths holds each leg's [lat, lon] points, see api.TripClient.JourneyPaths
+
+// WriteGeoJSON writes j as a FeatureCollection with a LineString per leg
+func WriteGeoJSON(w io.Writer, j api.Journey, paths [][][2]float64) error {
+	if len(paths) != len(j.Legs) {
+		return fmt.Errorf("have %d paths for %d legs", len(paths), len(j.Legs))
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for i, l := range j.Legs {
+		// GeoJSON wants [lon, lat]
+		line := make(orb.LineString, 0, len(paths[i]))
+		for _, p := range paths[i] {
+			line = append(line, orb.Point{p[1], p[0]})
+		}
+
+		feature := geojson.NewFeature(line)
+		feature.Properties = geojson.Properties{
+			"mode":        l.Mode().Name(),
+			"line":        lineName(l),
+			"origin":      l.Origin.Name,
+			"destination": l.Destination.Name,
+			"departure":   l.Origin.DepartureTimeEstimated,
+			"arrival":     l.Destination.ArrivalTimeEstimated,
+		}
+		if l.IsWalk() {
+			feature.Properties["colour"] = styles.HexColourForLine("WALK")
+		} else {
+			feature.Properties["colour"] = styles.HexColourForLine(l.Transportation.DisassembledName)
+			feature.Properties["towards"] = l.Transportation.Destination.Name
+		}
+		fc.Append(feature)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fc)
+}
+
+type gpxFile struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Version   string     `xml:"version,attr"`
+	Creator   string     `xml:"creator,attr"`
+	Namespace string     `xml:"xmlns,attr"`
+	Waypoints []gpxPoint `xml:"wpt"`
+	Tracks    []gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name        string       `xml:"name"`
+	Description string       `xml:"desc"`
+	Type        string       `xml:"type"`
+	Segments    []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time,omitempty"`
+	Name string  `xml:"name,omitempty"`
+}
+
+// WriteGPX writes j as a GPX 1.1 file, a track per leg and a waypoint
+// at every place the user gets on or off
+func WriteGPX(w io.Writer, j api.Journey, paths [][][2]float64) error {
+	if len(paths) != len(j.Legs) {
+		return fmt.Errorf("have %d paths for %d legs", len(paths), len(j.Legs))
+	}
+
+	gpx := gpxFile{
+		Version:   "1.1",
+		Creator:   "trip",
+		Namespace: "http://www.topografix.com/GPX/1/1",
+	}
+
+	for i, l := range j.Legs {
+		if i == 0 {
+			gpx.Waypoints = append(gpx.Waypoints, gpxWaypoint(l.Origin, l.Origin.DepartureTimeEstimated))
+		}
+		gpx.Waypoints = append(gpx.Waypoints, gpxWaypoint(l.Destination, l.Destination.ArrivalTimeEstimated))
+
+		var segment gpxSegment
+		for _, p := range paths[i] {
+			segment.Points = append(segment.Points, gpxPoint{Lat: p[0], Lon: p[1]})
+		}
+		// the ends of a leg are the only points we have times for
+		if n := len(segment.Points); n > 0 {
+			segment.Points[0].Time = gpxTime(l.Origin.DepartureTimeEstimated)
+			segment.Points[n-1].Time = gpxTime(l.Destination.ArrivalTimeEstimated)
+		}
+
+		gpx.Tracks = append(gpx.Tracks, gpxTrack{
+			Name:        fmt.Sprintf("%s to %s", lineName(l), l.Destination.DisassembledName),
+			Description: legDescription(l),
+			Type:        l.Mode().Name(),
+			Segments:    []gpxSegment{segment},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(gpx); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func gpxWaypoint(loc api.Location, at string) gpxPoint {
+	p := gpxPoint{Name: loc.Name, Time: gpxTime(at)}
+	if len(loc.Coord) >= 2 {
+		p.Lat, p.Lon = loc.Coord[0], loc.Coord[1]
+	}
+	return p
+}
+
+func gpxTime(raw string) string {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
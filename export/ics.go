@@ -0,0 +1,170 @@
+// Package export writes journeys out in formats other programs understand
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// ICSOptions changes how a journey becomes calendar events
+type ICSOptions struct {
+	WholeTrip bool // one event for the journey rather than one per transit leg
+}
+
+// WriteICS writes j as an iCalendar file, https://www.rfc-editor.org/rfc/rfc5545
+func WriteICS(w io.Writer, j api.Journey, opts ICSOptions) error {
+	if len(j.Legs) == 0 {
+		return fmt.Errorf("journey has no legs")
+	}
+
+	cal := icsWriter{w: w}
+	cal.line("BEGIN:VCALENDAR")
+	cal.line("VERSION:2.0")
+	cal.line("PRODID:-//trip//sydney public transport//EN")
+	cal.line("CALSCALE:GREGORIAN")
+
+	stamp := time.Now().UTC().Format(icsTimeFormat)
+	if opts.WholeTrip {
+		first, last := j.Legs[0], j.Legs[len(j.Legs)-1]
+		var description []string
+		for _, l := range j.Legs {
+			description = append(description, legDescription(l))
+		}
+		cal.event(stamp,
+			first.Origin.DepartureTimeEstimated, last.Destination.ArrivalTimeEstimated,
+			fmt.Sprintf("%s to %s", first.Origin.DisassembledName, last.Destination.DisassembledName),
+			strings.Join(description, "\n\n"),
+			first.Origin.Name,
+		)
+	} else {
+		for _, l := range j.Legs {
+			if l.IsWalk() {
+				continue
+			}
+			cal.event(stamp,
+				l.Origin.DepartureTimeEstimated, l.Destination.ArrivalTimeEstimated,
+				fmt.Sprintf("%s to %s", lineName(l), l.Destination.DisassembledName),
+				legDescription(l),
+				l.Origin.Name,
+			)
+		}
+	}
+
+	cal.line("END:VCALENDAR")
+	return cal.err
+}
+
+// FileName names an export after when the journey leaves, e.g. trip-20250801-0812.ics
+func FileName(j api.Journey, ext string) string {
+	name := "trip"
+	if len(j.Legs) > 0 {
+		if t, err := time.Parse(time.RFC3339, j.Legs[0].Origin.DepartureTimeEstimated); err == nil {
+			name += t.In(time.Local).Format("-20060102-1504")
+		}
+	}
+	return name + "." + ext
+}
+
+func lineName(l api.Leg) string {
+	if l.IsWalk() {
+		return "Walk"
+	}
+	return l.Transportation.DisassembledName
+}
+
+// e.g. "T4 Bondi Junction\nfrom Central, Platform 24 at 8:12am\n..."
+func legDescription(l api.Leg) string {
+	var lines []string
+
+	if l.IsWalk() {
+		lines = append(lines, "Walk")
+	} else {
+		lines = append(lines, strings.TrimSpace(l.Transportation.DisassembledName+" "+l.Transportation.Destination.Name))
+	}
+	lines = append(lines,
+		fmt.Sprintf("from %s at %s", stopWithPlatform(l.Origin), localTime(l.Origin.DepartureTimeEstimated)),
+		fmt.Sprintf("to %s at %s", stopWithPlatform(l.Destination), localTime(l.Destination.ArrivalTimeEstimated)),
+	)
+
+	return strings.Join(lines, "\n")
+}
+
+func stopWithPlatform(loc api.Location) string {
+	if platform := loc.Platform(); platform != "" && !strings.Contains(loc.Name, platform) {
+		return fmt.Sprintf("%s, %s", loc.Name, platform)
+	}
+	return loc.Name
+}
+
+func localTime(raw string) string {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return "n/a"
+	}
+	return t.In(time.Local).Format("3:04pm")
+}
+
+// icsWriter keeps the first error, so events can be written without checks
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (c *icsWriter) event(stamp string, start string, end string, summary string, description string, location string) {
+	startTime, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		c.err = fmt.Errorf("bad departure time %q: %w", start, err)
+		return
+	}
+	endTime, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		c.err = fmt.Errorf("bad arrival time %q: %w", end, err)
+		return
+	}
+
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + fmt.Sprintf("%s-%s@trip", startTime.UTC().Format(icsTimeFormat), icsUIDReplacer.Replace(summary)))
+	c.line("DTSTAMP:" + stamp)
+	c.line("DTSTART:" + startTime.UTC().Format(icsTimeFormat))
+	c.line("DTEND:" + endTime.UTC().Format(icsTimeFormat))
+	c.line("SUMMARY:" + icsEscape(summary))
+	c.line("DESCRIPTION:" + icsEscape(description))
+	c.line("LOCATION:" + icsEscape(location))
+	c.line("END:VEVENT")
+}
+
+// line writes a content line, folded at 75 octets as the RFC asks
+func (c *icsWriter) line(s string) {
+	if c.err != nil {
+		return
+	}
+
+	var folded strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > 75 {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	folded.WriteString("\r\n")
+
+	_, c.err = io.WriteString(c.w, folded.String())
+}
+
+var icsUIDReplacer = strings.NewReplacer(" ", "-", ",", "", ";", "", `\`, "")
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
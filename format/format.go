@@ -0,0 +1,126 @@
+// Package format words journeys the same way in the TUI and `trip plan`
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+)
+
+// Time converts a time string to a readable format, e.g. 8:12am
+func Time(loc *time.Location, rawTime string) string {
+	if rawTime == "" {
+		return "n/a"
+	}
+	parsed, err := time.Parse(time.RFC3339, rawTime)
+	if err != nil {
+		return "invalid time"
+	}
+	return parsed.In(loc).Format("3:04pm")
+}
+
+// LegTransport is the line name, or WALK for walking legs
+func LegTransport(l api.Leg) string {
+	if l.IsWalk() {
+		return "WALK"
+	}
+	return l.Transportation.DisassembledName
+}
+
+func LegOriginText(loc *time.Location, l api.Leg) string {
+	return fmt.Sprintf("%s | %s", l.Origin.DisassembledName, Time(loc, l.Origin.DepartureTimeEstimated))
+}
+
+func LegDestinationText(loc *time.Location, l api.Leg) string {
+	return fmt.Sprintf("%s | %s", l.Destination.DisassembledName, Time(loc, l.Destination.ArrivalTimeEstimated))
+}
+
+func LegTravelText(l api.Leg) string {
+	return fmt.Sprintf("Travel for %dmin", l.Duration/60)
+}
+
+// JourneyEndsText describes where and when a journey starts and finishes
+func JourneyEndsText(loc *time.Location, j api.Journey) (string, string) {
+	origin := j.Legs[0].Origin
+	destination := j.Legs[len(j.Legs)-1].Destination
+
+	originText := fmt.Sprintf("%s @%s", origin.DisassembledName, Time(loc, origin.DepartureTimeEstimated))
+	destText := fmt.Sprintf("%s @%s", destination.DisassembledName, Time(loc, destination.ArrivalTimeEstimated))
+	return originText, destText
+}
+
+// JourneyText is a compact plain text summary of a journey, one line for
+// the journey and one per leg
+func JourneyText(loc *time.Location, j api.Journey) string {
+	if len(j.Legs) == 0 {
+		return "This journey has no legs.\n"
+	}
+
+	var doc strings.Builder
+
+	originText, destText := JourneyEndsText(loc, j)
+	fmt.Fprintf(&doc, "%s → %s\n", originText, destText)
+
+	for _, l := range j.Legs {
+		fmt.Fprintf(&doc, "  [%s] %s > %s > %s\n", LegTransport(l), LegOriginText(loc, l), LegTravelText(l), LegDestinationText(loc, l))
+	}
+
+	return doc.String()
+}
+
+// JourneySummaryText is a shareable description of a journey, e.g.
+//
+//	Central 8:12am → Bondi Junction 8:30am (18min)
+//	T4 towards Bondi Junction, Platform 24: Central 8:12am → Bondi Junction 8:24am
+func JourneySummaryText(loc *time.Location, j api.Journey) string {
+	if len(j.Legs) == 0 {
+		return ""
+	}
+
+	var doc strings.Builder
+
+	first, last := j.Legs[0], j.Legs[len(j.Legs)-1]
+	fmt.Fprintf(&doc, "%s %s → %s %s",
+		first.Origin.DisassembledName, Time(loc, first.Origin.DepartureTimeEstimated),
+		last.Destination.DisassembledName, Time(loc, last.Destination.ArrivalTimeEstimated),
+	)
+	if minutes, ok := JourneyMinutes(j); ok {
+		fmt.Fprintf(&doc, " (%dmin)", minutes)
+	}
+	doc.WriteString("\n")
+
+	for _, l := range j.Legs {
+		service := "Walk"
+		if !l.IsWalk() {
+			service = l.Transportation.DisassembledName
+			if towards := l.Transportation.Destination.Name; towards != "" {
+				service += " towards " + towards
+			}
+		}
+		if platform := l.Origin.Platform(); platform != "" {
+			service += ", " + platform
+		}
+
+		fmt.Fprintf(&doc, "%s: %s %s → %s %s\n", service,
+			l.Origin.DisassembledName, Time(loc, l.Origin.DepartureTimeEstimated),
+			l.Destination.DisassembledName, Time(loc, l.Destination.ArrivalTimeEstimated),
+		)
+	}
+
+	return doc.String()
+}
+
+// JourneyMinutes is door to door travel time
+func JourneyMinutes(j api.Journey) (int, bool) {
+	start, err := time.Parse(time.RFC3339, j.Legs[0].Origin.DepartureTimeEstimated)
+	if err != nil {
+		return 0, false
+	}
+	end, err := time.Parse(time.RFC3339, j.Legs[len(j.Legs)-1].Destination.ArrivalTimeEstimated)
+	if err != nil {
+		return 0, false
+	}
+	return int(end.Sub(start).Minutes()), true
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -16,8 +17,12 @@ import (
 	"github.com/charmbracelet/wish/activeterm"
 	wishbtea "github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/cli"
+	"github.com/isobelmcrae/trip/state"
 	ui "github.com/isobelmcrae/trip/ui"
 	"github.com/joho/godotenv"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 const (
@@ -31,6 +36,11 @@ func main() {
 	}
 	sshMode := flag.Bool("ssh", false, "run as SSH‐served TUI")
 	sshAddr := flag.String("addr", defaultSSHAddr, "SSH listen address (host:port)")
+	from := flag.String("from", "", "origin stop name, ID or favourite")
+	to := flag.String("to", "", "destination stop name, ID or favourite")
+	at := flag.String("at", "", "depart at this time (15:04 or 2006-01-02 15:04)")
+	arriveBy := flag.String("arrive-by", "", "arrive by this time (15:04 or 2006-01-02 15:04)")
+	departures := flag.String("departures", "", "show the departure board for this stop")
 	flag.Parse()
 
 	// configure logging to file
@@ -46,17 +56,57 @@ func main() {
 	// FIXME: repair automatic timezone detection in the future
 	time.Local, _ = time.LoadLocation("Australia/Sydney")
 
+	// subcommands print and exit rather than starting the TUI
+	if flag.NArg() > 0 {
+		os.Exit(runSubcommand(flag.Arg(0), flag.Args()[1:]))
+	}
+
+	// the TUI can only log what's wrong with the database, so check it first
+	if err := checkDatabase(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(cli.ExitError)
+	}
+
 	if *sshMode {
 		runSSH(*sshAddr)
-	} else {
-		runLocal()
+		return
+	}
+
+	tripOpts, err := cli.TripOptions(*at, *arriveBy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(cli.ExitUsage)
+	}
+
+	runLocal(ui.LaunchOptions{From: *from, To: *to, Departures: *departures, Trip: tripOpts})
+}
+
+// runSubcommand runs e.g. `trip plan`, returning the exit code
+func runSubcommand(name string, args []string) int {
+	switch name {
+	case "plan":
+		return cli.RunPlan(args)
+	case "cache":
+		return cli.RunCache(args)
 	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+	return cli.ExitUsage
+}
+
+func checkDatabase() error {
+	db, err := api.OpenDatabase(state.DatabasePath)
+	if err != nil {
+		return err
+	}
+	return db.Close()
 }
 
 // runLocal starts your TUI in the current terminal
-func runLocal() {
-	m := ui.InitialiseRootModel()
-	p := tea.NewProgram(m, tea.WithAltScreen())
+func runLocal(opts ui.LaunchOptions) {
+	m := ui.InitialiseRootModel(state.ConfigDir())
+	m.Launch(opts)
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		log.Fatal("TUI error:", err)
 	}
@@ -67,6 +117,9 @@ func runSSH(addr string) {
 	server, err := wish.NewServer(
 		wish.WithAddress(addr),
 		wish.WithHostKeyPath(".ssh/id_ed25519"),
+		// accept anyone, keys are only used to tell users apart
+		wish.WithPublicKeyAuth(func(ssh.Context, ssh.PublicKey) bool { return true }),
+		wish.WithKeyboardInteractiveAuth(func(ssh.Context, gossh.KeyboardInteractiveChallenge) bool { return true }),
 		wish.WithMiddleware(
 			wishbtea.Middleware(sshHandler),
 			activeterm.Middleware(),
@@ -100,8 +153,16 @@ func runSSH(addr string) {
 // sshHandler wires each incoming SSH session to your Bubble Tea model
 func sshHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	_, winCh, _ := s.Pty()
+	// keep favourites and history per public key, users without one get
+	// a session that forgets everything when they leave
+	var userDir string
+	if key := s.PublicKey(); key != nil {
+		userDir = state.SSHUserDir(key.Marshal())
+	}
+
 	// pass session context so you can cancel on disconnect, etc.
-	m := ui.InitialiseRootModel()
+	m := ui.InitialiseRootModel(userDir)
+	m.Remote = true
 
 	// forward window‐resize events
 	go func() {
@@ -110,5 +171,5 @@ func sshHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 		}
 	}()
 
-	return m, []tea.ProgramOption{tea.WithAltScreen()}
+	return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
 }
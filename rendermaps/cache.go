@@ -1,34 +1,346 @@
 package rendermaps
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 )
 
-var (
-	localCache = createCache()
+// the disk cache's limits, unless $TRIP_TILE_CACHE_MB and
+// $TRIP_TILE_CACHE_DAYS say otherwise. Tiles are dropped least recently
+// used first once it's full, and after going unused for the max age
+const (
+	defaultCacheMaxBytes = 256 << 20
+	defaultCacheMaxAge   = 30 * 24 * time.Hour
+
+	// temporary files this old were left by a write that never finished
+	cacheTempMaxAge = time.Hour
+
+	// how long to go without caching after the directory couldn't be
+	// written to, e.g. while the disk's full
+	cacheRetryInterval = time.Minute
 )
 
-func createCache() string {
-	cache := filepath.Join(os.Getenv("HOME"), ".cache", "trip")
-	if err := os.MkdirAll(cache, 0755); err != nil {
-		panic(err)
+// CacheDir is where downloaded tiles are kept, $XDG_CACHE_HOME/trip
+// falling back to ~/.cache/trip
+func CacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(base, "trip")
+}
+
+func cacheMaxBytes() int64 {
+	if mb, err := strconv.ParseInt(os.Getenv("TRIP_TILE_CACHE_MB"), 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	return defaultCacheMaxBytes
+}
+
+func cacheMaxAge() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("TRIP_TILE_CACHE_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultCacheMaxAge
+}
+
+// cacheEntry is a cached response, kept on disk as a line of JSON for
+// the headers followed by the body
+type cacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Expires      time.Time `json:"expires"`
+
+	Body []byte `json:"-"`
+}
+
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+// diskCache keeps track of how big the cache directory is, so it can be
+// trimmed without listing it on every write. A file's modification time is
+// when it was last used
+type diskCache struct {
+	mu sync.Mutex
+
+	dir     string
+	size    int64     // bytes, -1 until the directory's been listed
+	retryAt time.Time // the directory couldn't be written to, skip it until then
+}
+
+var gCache = &diskCache{size: -1}
+
+// use switches to the current cache directory, which only changes when
+// the environment does
+func (c *diskCache) use() string {
+	if dir := CacheDir(); dir != c.dir {
+		c.dir, c.size, c.retryAt = dir, -1, time.Time{}
+	}
+	return c.dir
+}
+
+func cachePut(key string, entry *cacheEntry) error {
+	return gCache.put(key, entry)
+}
+
+func cacheGet(key string) (*cacheEntry, error) {
+	return gCache.get(key)
+}
+
+func (c *diskCache) put(key string, entry *cacheEntry) error {
+	header, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data := append(append(header, '\n'), entry.Body...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir := c.use()
+	if time.Now().Before(c.retryAt) {
+		return nil
+	}
+
+	// a tile being replaced no longer counts
+	var old int64
+	if info, err := os.Stat(filepath.Join(dir, key)); err == nil {
+		old = info.Size()
+	}
+	if err := c.write(dir, key, data); err != nil {
+		// carry on without a cache for a while rather than failing every tile
+		log.Printf("Tile cache %s unwritable, not caching tiles for %v: %v", dir, cacheRetryInterval, err)
+		c.retryAt = time.Now().Add(cacheRetryInterval)
+		return err
+	}
+
+	if c.size < 0 {
+		c.trim(dir)
+	} else if c.size += int64(len(data)) - old; c.size > cacheMaxBytes() {
+		c.trim(dir)
+	}
+	return nil
+}
+
+// write replaces the file for key all at once, so a reader never sees
+// half a tile
+func (c *diskCache) write(dir string, key string, data []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, key+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // does nothing once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
 	}
-	return cache
+	return os.Rename(tmp.Name(), filepath.Join(dir, key))
 }
 
-func cacheInsertKey(key string, value []byte) {
-	cacheFile := filepath.Join(localCache, key)
-	if err := os.WriteFile(cacheFile, value, 0644); err != nil {
-		panic(err)
+func (c *diskCache) get(key string) (*cacheEntry, error) {
+	c.mu.Lock()
+	path := filepath.Join(c.use(), key)
+	c.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.Sub(info.ModTime()) > cacheMaxAge() {
+		os.Remove(path)
+		return nil, fs.ErrNotExist
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	header, body, ok := bytes.Cut(data, []byte{'\n'})
+	if !ok {
+		return nil, errors.New("corrupt cache entry")
 	}
+	var entry cacheEntry
+	if err := json.Unmarshal(header, &entry); err != nil {
+		return nil, err
+	}
+	entry.Body = body
+
+	// mark it used, for the LRU
+	os.Chtimes(path, now, now)
+	return &entry, nil
 }
 
-func cacheGetKey(key string) ([]byte, error) {
-	cacheFile := filepath.Join(localCache, key)
-	data, err := os.ReadFile(cacheFile)
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	temp    bool // a write in progress, or one that never finished
+	legacy  bool // from before tiles were keyed by server, see HTTPProvider
+}
+
+// the names of the files the cache writes, anything else in the directory
+// is left alone. Legacy tiles can't be told apart by server, so they're
+// removed the next time the cache is trimmed rather than served
+var (
+	cacheTileName   = regexp.MustCompile(`^[0-9a-f]{8}-\d+-\d+-\d+$`)
+	cacheTempName   = regexp.MustCompile(`^[0-9a-f]{8}-\d+-\d+-\d+\.tmp\d+$`)
+	cacheLegacyName = regexp.MustCompile(`^\d+-\d+-\d+$`)
+)
+
+// listCache lists the cache's own files in dir
+func listCache(dir string) ([]cacheFile, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	files := make([]cacheFile, 0, len(entries))
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		name := e.Name()
+		temp, legacy := cacheTempName.MatchString(name), cacheLegacyName.MatchString(name)
+		if !temp && !legacy && !cacheTileName.MatchString(name) {
+			continue
+		}
+		files = append(files, cacheFile{filepath.Join(dir, name), info.Size(), info.ModTime(), temp, legacy})
+	}
+	return files, nil
+}
+
+// trim removes tiles unused for the max age and legacy tiles, then the
+// least recently used until the cache is back under its size, recounting
+// it as it goes. Writes in progress don't count towards the size
+func (c *diskCache) trim(dir string) {
+	files, err := listCache(dir)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	maxAge, maxBytes := cacheMaxAge(), cacheMaxBytes()
+
+	// oldest first
+	slices.SortFunc(files, func(a, b cacheFile) int { return a.modTime.Compare(b.modTime) })
+
+	var size int64
+	kept := files[:0]
+	for _, f := range files {
+		age := now.Sub(f.modTime)
+		if age > maxAge || f.legacy || (f.temp && age > cacheTempMaxAge) {
+			os.Remove(f.path)
+			continue
+		}
+		if f.temp {
+			continue
+		}
+		size += f.size
+		kept = append(kept, f)
+	}
+
+	if size > maxBytes {
+		// leave some room, so the next few writes don't each trim again
+		target := maxBytes * 9 / 10
+		for _, f := range kept {
+			if size <= target {
+				break
+			}
+			if os.Remove(f.path) == nil {
+				size -= f.size
+			}
+		}
+	}
+	c.size = size
+}
+
+// CacheStats describes the tile cache, for `trip cache stats`
+type CacheStats struct {
+	Dir      string
+	Files    int
+	Bytes    int64
+	MaxBytes int64
+	MaxAge   time.Duration
+	Oldest   time.Time // least recently used
+	Newest   time.Time
+}
+
+func TileCacheStats() (CacheStats, error) {
+	dir := CacheDir()
+	stats := CacheStats{Dir: dir, MaxBytes: cacheMaxBytes(), MaxAge: cacheMaxAge()}
+
+	files, err := listCache(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	for _, f := range files {
+		if f.temp || f.legacy {
+			continue
+		}
+		stats.Files++
+		stats.Bytes += f.size
+		if stats.Oldest.IsZero() || f.modTime.Before(stats.Oldest) {
+			stats.Oldest = f.modTime
+		}
+		if f.modTime.After(stats.Newest) {
+			stats.Newest = f.modTime
+		}
+	}
+	return stats, nil
+}
+
+// PurgeTileCache empties the tile cache, returning how many files and
+// bytes it removed
+func PurgeTileCache() (int, int64, error) {
+	gCache.mu.Lock()
+	defer gCache.mu.Unlock()
+
+	files, err := listCache(gCache.use())
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var removed int
+	var freed int64
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil {
+			return removed, freed, err
+		}
+		removed++
+		freed += f.size
+	}
+	gCache.size = 0
+	return removed, freed, nil
 }
@@ -134,6 +134,16 @@ func (c *Canvas) Text(text string, x, y int, color string) {
 	}
 }
 
+// textFrom writes text starting at x rather than centred on it
+func (c *Canvas) textFrom(text string, x, y int, color string) {
+	for i, r := range []rune(text) {
+		if idx, ok := c.project(x+i*2, y); ok {
+			c.charBuffer[idx] = r
+			c.colorBuffer[idx] = color
+		}
+	}
+}
+
 func (c *Canvas) Frame() string {
 	var sb strings.Builder
 	termReset := "\x1B[0m"
@@ -195,6 +205,28 @@ func (c *Canvas) SplatLineGeo(
 	c.line(canvasP1, canvasP2, hexToANSI(colour), true)
 }
 
+// LineGeo draws a thin braille line, use before drawing the map so it
+// keeps its colour where the two overlap
+func (c *Canvas) LineGeo(
+	originLat, originLon, destLat, destLon float64,
+	mapCenterLat, mapCenterLon,
+	mapZoom float64, colour string,
+) {
+	canvasP1 := geoToPixel(
+		originLat, originLon,
+		mapCenterLat, mapCenterLon, mapZoom,
+		c.width, c.height,
+	)
+
+	canvasP2 := geoToPixel(
+		destLat, destLon,
+		mapCenterLat, mapCenterLon, mapZoom,
+		c.width, c.height,
+	)
+
+	c.line(canvasP1, canvasP2, hexToANSI(colour))
+}
+
 func (c *Canvas) line(p1, p2 orb.Point, color string, impl ...bool) {
 	var setPixel bool
 	if len(impl) > 0 && impl[0] {
@@ -30,45 +30,32 @@ type Renderer struct {
 
 	tileSize float64
 	zoom float64
+	centerLat float64
+	centerLon float64
 }
 
 func RenderMap(width, height int, lat, lon float64, zoom float64) *Renderer {
 	canvas := NewCanvas(width*pixelWidthPerChar, height*pixelHeightPerChar) // Canvas is in pixels (2x4 per char)
 	labelBuffer := NewLabelBuffer()
 
-	z := baseZoom(zoom)
-	centerX, centerY := ll2tile(lon, lat, z)
-	tileSize := tilesizeAtZoom(zoom)
-	gridSize := math.Pow(2, float64(z))
+	centerX, centerY, tileSize := tileGeometry(lat, lon, zoom)
 
 	fetchedTiles := make(chan tileJob)
 	var wg sync.WaitGroup
 
-	for ty := math.Floor(centerY) - 1; ty <= math.Floor(centerY)+1; ty++ {
-		for tx := math.Floor(centerX) - 1; tx <= math.Floor(centerX)+1; tx++ {
-			tileX := int(math.Mod(tx, gridSize))
-			if tileX < 0 {
-				tileX += int(gridSize)
-			}
-			tileY := int(ty)
-
-			if tileY < 0 || tileY >= int(gridSize) {
-				continue
-			}
-
-			wg.Add(1)
-			go func(z, x, y int, tx, ty float64) {
-				defer wg.Done()
-				tile, err := gTs.GetTile(z, x, y)
-				if err == nil {
-					pos := orb.Point{
-						float64(canvas.width)/2 - (centerX-tx)*tileSize,
-						float64(canvas.height)/2 - (centerY-ty)*tileSize,
-					}
-					fetchedTiles <- tileJob{tile: tile, pos: pos}
+	for _, ref := range visibleTiles(canvas.width, canvas.height, lat, lon, zoom) {
+		wg.Add(1)
+		go func(ref tileRef) {
+			defer wg.Done()
+			tile, err := tileSource().GetTile(ref.z, ref.x, ref.y)
+			if err == nil {
+				pos := orb.Point{
+					float64(canvas.width)/2 - (centerX-ref.tx)*tileSize,
+					float64(canvas.height)/2 - (centerY-ref.ty)*tileSize,
 				}
-			}(z, tileX, tileY, tx, ty)
-		}
+				fetchedTiles <- tileJob{tile: tile, pos: pos}
+			}
+		}(ref)
 	}
 
 	go func() {
@@ -87,9 +74,52 @@ func RenderMap(width, height int, lat, lon float64, zoom float64) *Renderer {
 		jobs:        jobs,
 		tileSize:    tileSize,
 		zoom:        zoom,
+		centerLat:   lat,
+		centerLon:   lon,
 	}
 }
 
+// tileRef is a tile to draw, and where it sits in the grid of tiles around
+// the centre, wrapping around the antimeridian
+type tileRef struct {
+	z, x, y int
+	tx, ty  float64
+}
+
+// tileGeometry is the centre's position in tiles, and how many pixels a
+// tile takes up
+func tileGeometry(lat, lon float64, zoom float64) (centerX float64, centerY float64, tileSize float64) {
+	centerX, centerY = ll2tile(lon, lat, baseZoom(zoom))
+	return centerX, centerY, tilesizeAtZoom(zoom)
+}
+
+// visibleTiles is every tile a canvas width by height pixels overlaps
+func visibleTiles(width, height int, lat, lon float64, zoom float64) []tileRef {
+	z := baseZoom(zoom)
+	centerX, centerY, tileSize := tileGeometry(lat, lon, zoom)
+	gridSize := math.Pow(2, float64(z))
+
+	halfTilesX := float64(width) / 2 / tileSize
+	halfTilesY := float64(height) / 2 / tileSize
+
+	var refs []tileRef
+	for ty := math.Floor(centerY - halfTilesY); ty <= math.Floor(centerY+halfTilesY); ty++ {
+		for tx := math.Floor(centerX - halfTilesX); tx <= math.Floor(centerX+halfTilesX); tx++ {
+			tileX := int(math.Mod(tx, gridSize))
+			if tileX < 0 {
+				tileX += int(gridSize)
+			}
+			tileY := int(ty)
+
+			if tileY < 0 || tileY >= int(gridSize) {
+				continue
+			}
+			refs = append(refs, tileRef{z, tileX, tileY, tx, ty})
+		}
+	}
+	return refs
+}
+
 func RenderMapOneshot(width, height int, lat, lon float64, zoom float64) string {
 	renderer := RenderMap(width, height, lat, lon, zoom)
 	drawOrder := []string{"landuse", "water", "building", "road", "admin", "place_label", "poi_label"}
@@ -111,6 +141,33 @@ func (r *Renderer) Frame() string {
 	return r.Canvas.Frame()
 }
 
+// Pin puts marker at a coordinate, keeping map labels drawn afterwards
+// off it
+func (r *Renderer) Pin(lat, lon float64, colour string, marker rune) {
+	p := geoToPixel(lat, lon, r.centerLat, r.centerLon, r.zoom, r.Canvas.width, r.Canvas.height)
+	x, y := int(p.X()), int(p.Y())
+
+	if idx, ok := r.Canvas.project(x, y); ok {
+		r.Canvas.charBuffer[idx] = marker
+		r.Canvas.colorBuffer[idx] = hexToANSI(colour)
+		r.labelBuffer.reserve(x/pixelWidthPerChar, y/pixelHeightPerChar, 1)
+	}
+}
+
+// Label writes text just right of a coordinate unless it would run into
+// another label, reporting whether it did. Labels written before the map's
+// own take priority over them
+func (r *Renderer) Label(text string, lat, lon float64, colour string) bool {
+	p := geoToPixel(lat, lon, r.centerLat, r.centerLon, r.zoom, r.Canvas.width, r.Canvas.height)
+	charX, charY := int(p.X())/pixelWidthPerChar+2, int(p.Y())/pixelHeightPerChar
+
+	if !r.labelBuffer.WriteIfPossible(text, charX, charY) {
+		return false
+	}
+	r.Canvas.textFrom(text, charX*pixelWidthPerChar, charY*pixelHeightPerChar, hexToANSI(colour))
+	return true
+}
+
 /* func RenderMapString(width, height int, lat, lon float64, zoom float64) (string, error) {
 	canvas, err := RenderMap(width, height, lat, lon, zoom)
 	if err != nil {
@@ -0,0 +1,122 @@
+package rendermaps
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const earthCircumference = 40075016.686 // metres, at the equator
+
+// LegendEntry is one line drawn on the map, shown in the legend
+type LegendEntry struct {
+	Name   string
+	Colour string
+}
+
+// overlayLine is one row of the overlay, made of differently coloured parts
+type overlayLine []overlayPart
+
+type overlayPart struct {
+	text   string
+	colour string // ANSI, or empty for the terminal's own
+}
+
+func (l overlayLine) width() int {
+	w := 0
+	for _, p := range l {
+		w += runewidth.StringWidth(p.text)
+	}
+	return w
+}
+
+// DrawOverlay puts a compass, scale bar and legend in a corner of the map,
+// the first one free of labels going clockwise from the bottom left. Call
+// it last, after the labels are drawn
+func (r *Renderer) DrawOverlay(legend []LegendEntry) {
+	lines := []overlayLine{
+		{{text: "N↑ ", colour: ""}, {text: r.scaleBar(), colour: ""}},
+	}
+	for _, entry := range legend {
+		lines = append(lines, overlayLine{
+			{text: "━━ ", colour: hexToANSI(entry.Colour)},
+			{text: entry.Name},
+		})
+	}
+
+	width := 0
+	for _, l := range lines {
+		width = max(width, l.width())
+	}
+	// a column of padding either side
+	width += 2
+	height := len(lines)
+
+	cols, rows := r.Canvas.width/pixelWidthPerChar, r.Canvas.height/pixelHeightPerChar
+	if width > cols || height > rows {
+		return
+	}
+
+	corners := [][2]int{
+		{0, rows - height},            // bottom left
+		{0, 0},                        // top left
+		{cols - width, 0},             // top right
+		{cols - width, rows - height}, // bottom right
+	}
+	x, y := corners[0][0], corners[0][1]
+	for _, c := range corners {
+		if r.labelBuffer.isFree(c[0], c[1], c[0]+width-1, c[1]+height-1) {
+			x, y = c[0], c[1]
+			break
+		}
+	}
+
+	for row, l := range lines {
+		// blank out the map behind the overlay
+		r.Canvas.textFrom(fmt.Sprintf("%*s", width, ""), x*pixelWidthPerChar, (y+row)*pixelHeightPerChar, "")
+
+		col := x + 1
+		for _, p := range l {
+			r.Canvas.textFrom(p.text, col*pixelWidthPerChar, (y+row)*pixelHeightPerChar, p.colour)
+			col += runewidth.StringWidth(p.text)
+		}
+	}
+}
+
+// scaleBar is a bar a round distance long, e.g. "├───┤ 500 m", sized for
+// the map's zoom and latitude
+func (r *Renderer) scaleBar() string {
+	metresPerPixel := earthCircumference * math.Cos(r.centerLat*math.Pi/180) / (ProjectSize * math.Pow(2, r.zoom))
+	metresPerChar := metresPerPixel * pixelWidthPerChar
+
+	// the longest round distance that fits in about 12 characters
+	const maxChars = 12
+	distance := niceDistance(metresPerChar * maxChars)
+	chars := max(int(math.Round(distance/metresPerChar)), 2)
+
+	bar := "├"
+	for range chars - 2 {
+		bar += "─"
+	}
+	bar += "┤ "
+
+	if distance >= 1000 {
+		return bar + fmt.Sprintf("%g km", distance/1000)
+	}
+	return bar + fmt.Sprintf("%g m", distance)
+}
+
+// niceDistance rounds metres down to 1, 2 or 5 times a power of ten
+func niceDistance(metres float64) float64 {
+	if metres <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(metres)))
+	for _, step := range []float64{5, 2, 1} {
+		if step*magnitude <= metres {
+			return step * magnitude
+		}
+	}
+	return magnitude
+}
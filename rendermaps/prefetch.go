@@ -0,0 +1,42 @@
+package rendermaps
+
+import "sync"
+
+// MapView is where a map looks
+type MapView struct {
+	Lat, Lon, Zoom float64
+}
+
+// how many tiles are prefetched at once, shared by every session so they
+// don't crowd out the tiles being drawn
+const prefetchWorkers = 4
+
+var prefetchSlots = make(chan struct{}, prefetchWorkers)
+
+// Prefetch loads the tiles maps of width by height characters need to
+// show each view, so drawing them later doesn't wait on the network. It
+// returns once they're all loaded, or failed to
+func Prefetch(width, height int, views []MapView) {
+	seen := map[tileRef]bool{}
+	var wg sync.WaitGroup
+
+	for _, v := range views {
+		for _, ref := range visibleTiles(width*pixelWidthPerChar, height*pixelHeightPerChar, v.Lat, v.Lon, v.Zoom) {
+			// the same tile from either side of the antimeridian is one tile
+			ref.tx, ref.ty = 0, 0
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			wg.Add(1)
+			prefetchSlots <- struct{}{}
+			go func(ref tileRef) {
+				defer wg.Done()
+				defer func() { <-prefetchSlots }()
+				tileSource().GetTile(ref.z, ref.x, ref.y)
+			}(ref)
+		}
+	}
+	wg.Wait()
+}
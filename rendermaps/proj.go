@@ -22,21 +22,36 @@ func yMercatorNormalized(lat float64) float64 {
 // two coordinates within a given view size. The view size is provided in terminal
 // characters (width, height).
 func FocusOn(lat1, lon1, lat2, lon2 float64, viewWidthChars, viewHeightChars int) (centerLat, centerLon, zoom float64) {
+	return FocusOnPoints([][2]float64{{lat1, lon1}, {lat2, lon2}}, viewWidthChars, viewHeightChars)
+}
+
+// FocusOnPoints is FocusOn for any number of [lat, lon] points, fitting
+// their bounding box within the view
+func FocusOnPoints(points [][2]float64, viewWidthChars, viewHeightChars int) (centerLat, centerLon, zoom float64) {
+	if len(points) == 0 {
+		return 0, 0, MinZoom
+	}
+
 	// Apply some padding so the points are not at the very edge of the map.
 	// A value of 0.8 means the bounding box will take up 80% of the view.
 	padding := 0.8
 	viewWidthPixels := float64(viewWidthChars*pixelWidthPerChar) * padding
 	viewHeightPixels := float64(viewHeightChars*pixelHeightPerChar) * padding
 
-	// If the two points are (almost) the same, we can't calculate a span.
+	// 1. Calculate the bounding box, spans, and center point.
+	minLat, maxLat := points[0][0], points[0][0]
+	minLon, maxLon := points[0][1], points[0][1]
+	for _, p := range points[1:] {
+		minLat, maxLat = math.Min(minLat, p[0]), math.Max(maxLat, p[0])
+		minLon, maxLon = math.Min(minLon, p[1]), math.Max(maxLon, p[1])
+	}
+
+	// If the points are (almost) the same, we can't calculate a span.
 	// Default to a fixed high zoom level centered on the point.
-	if math.Abs(lat1-lat2) < 1e-6 && math.Abs(lon1-lon2) < 1e-6 {
-		return lat1, lon1, MaxZoom // A good default zoom for a single point
+	if maxLat-minLat < 1e-6 && maxLon-minLon < 1e-6 {
+		return points[0][0], points[0][1], MaxZoom // A good default zoom for a single point
 	}
 
-	// 1. Calculate the bounding box, spans, and center point.
-	minLat := math.Min(lat1, lat2)
-	maxLat := math.Max(lat1, lat2)
 	centerLat = (minLat + maxLat) / 2
 
 	// The latitude span in normalized Mercator coordinates.
@@ -44,19 +59,26 @@ func FocusOn(lat1, lon1, lat2, lon2 float64, viewWidthChars, viewHeightChars int
 
 	// Handle longitude carefully due to the antimeridian (180° longitude).
 	var lonSpan float64
-	if math.Abs(lon1-lon2) > 180 {
-		// The shortest path crosses the antimeridian.
-		maxLonVal := math.Max(lon1, lon2)
-		minLonVal := math.Min(lon1, lon2)
-		lonSpan = 360 - (maxLonVal - minLonVal)
-		centerLon = (maxLonVal + minLonVal + 360) / 2
+	if maxLon-minLon > 180 {
+		// The shortest path crosses the antimeridian, so measure the
+		// western points as if they were past 180°.
+		minLon, maxLon = math.Inf(1), math.Inf(-1)
+		for _, p := range points {
+			lon := p[1]
+			if lon < 0 {
+				lon += 360
+			}
+			minLon, maxLon = math.Min(minLon, lon), math.Max(maxLon, lon)
+		}
+		lonSpan = maxLon - minLon
+		centerLon = (maxLon + minLon) / 2
 		if centerLon > 180 {
 			centerLon -= 360
 		}
 	} else {
 		// The path does not cross the antimeridian.
-		lonSpan = math.Abs(lon1 - lon2)
-		centerLon = (lon1 + lon2) / 2
+		lonSpan = maxLon - minLon
+		centerLon = (minLon + maxLon) / 2
 	}
 
 	// 2. Calculate the required zoom level.
@@ -101,6 +123,27 @@ func FocusOn(lat1, lon1, lat2, lon2 float64, viewWidthChars, viewHeightChars int
 	return centerLat, centerLon, zoom
 }
 
+// Pan moves the centre of a map at zoom by dx columns and dy rows of the
+// terminal, positive being right and down
+func Pan(centerLat, centerLon, zoom float64, dx, dy int) (lat, lon float64) {
+	worldSize := ProjectSize * math.Pow(2, zoom)
+
+	lon = centerLon + float64(dx*pixelWidthPerChar)/worldSize*360
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon < -180 {
+		lon += 360
+	}
+
+	y := yMercatorNormalized(centerLat) + float64(dy*pixelHeightPerChar)/worldSize
+	y = math.Max(0, math.Min(1, y))
+	lat = math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+	lat = math.Max(-MaxLat, math.Min(MaxLat, lat))
+
+	return lat, lon
+}
+
 // tileCoord represents a Web Mercator tile coordinate at a specific zoom level.
 type tileCoord struct {
 	X, Y, Z float64
@@ -0,0 +1,63 @@
+package rendermaps
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TileProvider supplies the raw vector data of a tile, gzipped or not
+type TileProvider interface {
+	Tile(z, x, y int) ([]byte, error)
+}
+
+// ErrNoTile is returned by providers for a tile they don't have, such as
+// one outside an extract
+var ErrNoTile = errors.New("no such tile")
+
+var (
+	gTs   *TileSource
+	gTsMu sync.Mutex
+)
+
+// tileSource is where maps get their tiles, opened from $TRIP_TILES the
+// first time it's needed so a .env file has been loaded by then
+func tileSource() *TileSource {
+	gTsMu.Lock()
+	defer gTsMu.Unlock()
+
+	if gTs == nil {
+		provider, err := OpenTileProvider(os.Getenv("TRIP_TILES"))
+		if err != nil {
+			log.Printf("Failed to open tiles %q, using %s: %v", os.Getenv("TRIP_TILES"), TileSourceURL, err)
+			provider = NewHTTPProvider(TileSourceURL)
+		}
+		gTs = NewTileSource(provider, gStyler)
+	}
+	return gTs
+}
+
+// SetTileProvider switches where maps get their tiles from, forgetting any
+// already loaded
+func SetTileProvider(provider TileProvider) {
+	gTsMu.Lock()
+	defer gTsMu.Unlock()
+	gTs = NewTileSource(provider, gStyler)
+}
+
+// OpenTileProvider picks a provider for source, which is an .mbtiles or
+// .pmtiles file, or else a tile server's URL. Empty is the mapscii server
+func OpenTileProvider(source string) (TileProvider, error) {
+	switch {
+	case source == "":
+		return NewHTTPProvider(TileSourceURL), nil
+	case strings.HasSuffix(source, ".mbtiles"):
+		return OpenMBTiles(source)
+	case strings.HasSuffix(source, ".pmtiles"):
+		return OpenPMTiles(source)
+	default:
+		return NewHTTPProvider(source), nil
+	}
+}
@@ -0,0 +1,211 @@
+package rendermaps
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sent unless $TRIP_TILES_USER_AGENT says otherwise, so tile servers
+	// know who's asking
+	DefaultUserAgent = "trip (+https://github.com/isobelmcrae/trip)"
+
+	// how long a tile is kept without asking again when the server
+	// doesn't say
+	defaultTileMaxAge = 7 * 24 * time.Hour
+)
+
+// HTTPProvider downloads tiles from a tile server, keeping them in the
+// disk cache for as long as the server allows and revalidating them after
+type HTTPProvider struct {
+	template  string
+	userAgent string
+	client    *http.Client
+
+	// cached tiles are keyed by server, so switching servers doesn't mix
+	// up their tiles
+	cachePrefix string
+}
+
+// NewHTTPProvider downloads tiles from template, a URL with {z}, {x} and
+// {y} in it. A URL without them is a server laid out like mapscii's
+func NewHTTPProvider(template string) *HTTPProvider {
+	if !strings.Contains(template, "{z}") {
+		if !strings.HasSuffix(template, "/") {
+			template += "/"
+		}
+		template += "{z}/{x}/{y}.pbf"
+	}
+
+	userAgent := os.Getenv("TRIP_TILES_USER_AGENT")
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	sum := sha256.Sum256([]byte(template))
+	return &HTTPProvider{
+		template:    template,
+		userAgent:   userAgent,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		cachePrefix: hex.EncodeToString(sum[:4]),
+	}
+}
+
+func (p *HTTPProvider) url(z, x, y int) string {
+	return strings.NewReplacer(
+		"{z}", strconv.Itoa(z),
+		"{x}", strconv.Itoa(x),
+		"{y}", strconv.Itoa(y),
+	).Replace(p.template)
+}
+
+func (p *HTTPProvider) Tile(z, x, y int) ([]byte, error) {
+	key := fmt.Sprintf("%s-%d-%d-%d", p.cachePrefix, z, x, y)
+
+	cached, err := cacheGet(key)
+	if err != nil {
+		cached = nil
+	}
+	if cached != nil && cached.fresh(time.Now()) {
+		return cached.Body, nil
+	}
+
+	entry, store, err := p.fetch(z, x, y, cached)
+	if err != nil {
+		// an old tile is better than none while the server's down
+		if cached != nil && !errors.Is(err, ErrNoTile) {
+			log.Printf("Using stale tile %d/%d/%d: %v", z, x, y, err)
+			return cached.Body, nil
+		}
+		return nil, err
+	}
+
+	// written back after a 304 too, so the new expiry is kept and the tile
+	// isn't revalidated again until then
+	if store {
+		if err := cachePut(key, entry); err != nil {
+			log.Printf("Failed to cache tile %d/%d/%d: %v", z, x, y, err)
+		}
+	}
+	return entry.Body, nil
+}
+
+// fetch asks the server for a tile, revalidating cached if there is one,
+// and reports whether the server lets it be stored. Only tiles the server
+// actually sent come back, anything else is an error
+func (p *HTTPProvider) fetch(z, x, y int, cached *cacheEntry) (*cacheEntry, bool, error) {
+	req, err := http.NewRequest("GET", p.url(z, x, y), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		expires, store := cacheExpiry(resp.Header, time.Now())
+		cached.Expires = expires
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			cached.ETag = etag
+		}
+		if modified := resp.Header.Get("Last-Modified"); modified != "" {
+			cached.LastModified = modified
+		}
+		return cached, store, nil
+
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := checkTile(body); err != nil {
+			return nil, false, fmt.Errorf("tile %d/%d/%d: %w", z, x, y, err)
+		}
+		expires, store := cacheExpiry(resp.Header, time.Now())
+		return &cacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Expires:      expires,
+			Body:         body,
+		}, store, nil
+
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent:
+		return nil, false, ErrNoTile
+
+	default:
+		return nil, false, fmt.Errorf("tile %d/%d/%d: %s", z, x, y, resp.Status)
+	}
+}
+
+// checkTile makes sure a 200's body is a vector tile, gzipped or not, and
+// not e.g. a captive portal's page, before it's kept. Empty tiles are fine
+func checkTile(body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+	// gzip's magic number, or the tag of a tile's first layer
+	if !bytes.HasPrefix(body, []byte{0x1f, 0x8b}) && body[0] != 0x1a {
+		return errors.New("not a vector tile")
+	}
+	if _, err := decodeTile(body); err != nil {
+		return fmt.Errorf("not a vector tile: %w", err)
+	}
+	return nil
+}
+
+// cacheExpiry is when a response stops being fresh going by its
+// Cache-Control or Expires headers, and whether it may be stored at all.
+// no-cache responses are stored but revalidated every time
+func cacheExpiry(header http.Header, now time.Time) (time.Time, bool) {
+	maxAge := -1
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-store":
+			return time.Time{}, false
+		case "no-cache":
+			maxAge = 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && maxAge != 0 {
+				maxAge = seconds
+			}
+		}
+	}
+	if maxAge >= 0 {
+		return now.Add(time.Duration(maxAge) * time.Second), true
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		// an invalid date means already expired
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return now, true
+		}
+		return t, true
+	}
+
+	return now.Add(defaultTileMaxAge), true
+}
@@ -0,0 +1,60 @@
+package rendermaps
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// MBTilesProvider reads tiles from an MBTiles file, a SQLite database of
+// tiles such as an extract of one city
+// https://github.com/mapbox/mbtiles-spec
+type MBTilesProvider struct {
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+func OpenMBTiles(path string) (*MBTilesProvider, error) {
+	// a relative path, or a Windows drive, would be read as the URI's host
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	uriPath := filepath.ToSlash(abs)
+	if !strings.HasPrefix(uriPath, "/") {
+		uriPath = "/" + uriPath
+	}
+	uri := url.URL{Scheme: "file", Path: uriPath, RawQuery: "mode=ro"}
+	db, err := sql.Open("sqlite3", uri.String())
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := db.Prepare("SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &MBTilesProvider{db: db, stmt: stmt}, nil
+}
+
+func (p *MBTilesProvider) Tile(z, x, y int) ([]byte, error) {
+	// rows count up from the south, TMS style
+	row := (1 << z) - 1 - y
+
+	var data []byte
+	err := p.stmt.QueryRow(z, x, row).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoTile
+	}
+	return data, err
+}
+
+func (p *MBTilesProvider) Close() error {
+	p.stmt.Close()
+	return p.db.Close()
+}
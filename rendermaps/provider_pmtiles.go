@@ -0,0 +1,268 @@
+package rendermaps
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// PMTiles v3, a single file archive of tiles indexed by directories
+// https://github.com/protomaps/PMTiles/blob/main/spec/v3/spec.md
+const (
+	pmtilesHeaderSize = 127
+
+	pmCompressionUnknown = 0
+	pmCompressionNone    = 1
+	pmCompressionGzip    = 2
+
+	pmTileTypeUnknown = 0
+	pmTileTypeMVT     = 1
+
+	// deeper than this the archive is broken
+	pmMaxDepth = 4
+)
+
+// pmEntry is a run of tiles in the archive, or a leaf directory when
+// runLength is 0
+type pmEntry struct {
+	tileID    uint64
+	offset    uint64
+	length    uint64
+	runLength uint64
+}
+
+// PMTilesProvider reads tiles from a PMTiles file
+type PMTilesProvider struct {
+	file *os.File
+
+	internalCompression byte
+	leafOffset          uint64
+	dataOffset          uint64
+	dataLength          uint64
+	root                []pmEntry
+
+	// leaf directories are read as they're needed, by offset
+	leaves map[uint64][]pmEntry
+	mu     sync.Mutex
+}
+
+func OpenPMTiles(path string) (*PMTilesProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := readPMTiles(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return p, nil
+}
+
+func readPMTiles(file *os.File) (*PMTilesProvider, error) {
+	header := make([]byte, pmtilesHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if string(header[:7]) != "PMTiles" || header[7] != 3 {
+		return nil, errors.New("not a PMTiles v3 archive")
+	}
+
+	u64 := func(at int) uint64 { return binary.LittleEndian.Uint64(header[at : at+8]) }
+	p := &PMTilesProvider{
+		file:                file,
+		internalCompression: header[97],
+		leafOffset:          u64(40),
+		dataOffset:          u64(56),
+		dataLength:          u64(64),
+		leaves:              make(map[uint64][]pmEntry),
+	}
+
+	switch p.internalCompression {
+	case pmCompressionUnknown, pmCompressionNone, pmCompressionGzip:
+	default:
+		return nil, fmt.Errorf("unsupported directory compression %d", p.internalCompression)
+	}
+	// gzipped tiles are unzipped when they're loaded, other compression isn't
+	if c := header[98]; c != pmCompressionUnknown && c != pmCompressionNone && c != pmCompressionGzip {
+		return nil, fmt.Errorf("unsupported tile compression %d", c)
+	}
+	if t := header[99]; t != pmTileTypeUnknown && t != pmTileTypeMVT {
+		return nil, fmt.Errorf("tiles aren't vector tiles, type %d", t)
+	}
+
+	root, err := p.readDirectory(u64(8), u64(16))
+	if err != nil {
+		return nil, err
+	}
+	p.root = root
+	return p, nil
+}
+
+func (p *PMTilesProvider) Tile(z, x, y int) ([]byte, error) {
+	id := pmTileID(z, x, y)
+
+	dir := p.root
+	for range pmMaxDepth {
+		entry, ok := findPMEntry(dir, id)
+		if !ok {
+			return nil, ErrNoTile
+		}
+
+		if entry.runLength > 0 {
+			if entry.offset > p.dataLength || entry.length > p.dataLength-entry.offset {
+				return nil, fmt.Errorf("tile %d/%d/%d is outside the archive's tile data", z, x, y)
+			}
+			data := make([]byte, entry.length)
+			if _, err := p.file.ReadAt(data, int64(p.dataOffset+entry.offset)); err != nil {
+				return nil, err
+			}
+			return data, nil
+		}
+
+		leaf, err := p.leaf(entry.offset, entry.length)
+		if err != nil {
+			return nil, err
+		}
+		dir = leaf
+	}
+	return nil, ErrNoTile
+}
+
+func (p *PMTilesProvider) Close() error {
+	return p.file.Close()
+}
+
+func (p *PMTilesProvider) leaf(offset uint64, length uint64) ([]pmEntry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if leaf, ok := p.leaves[offset]; ok {
+		return leaf, nil
+	}
+	leaf, err := p.readDirectory(p.leafOffset+offset, length)
+	if err != nil {
+		return nil, err
+	}
+	p.leaves[offset] = leaf
+	return leaf, nil
+}
+
+// readDirectory decodes the directory at offset: a count, then each
+// column of the entries in turn as varints
+func (p *PMTilesProvider) readDirectory(offset uint64, length uint64) ([]pmEntry, error) {
+	var r io.Reader = io.NewSectionReader(p.file, int64(offset), int64(length))
+	if p.internalCompression == pmCompressionGzip {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(bytes.NewReader(data))
+
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(data)) {
+		return nil, errors.New("corrupt directory")
+	}
+	entries := make([]pmEntry, n)
+
+	// ids are stored as the difference from the last
+	var id uint64
+	for i := range entries {
+		delta, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		id += delta
+		entries[i].tileID = id
+	}
+	for i := range entries {
+		if entries[i].runLength, err = binary.ReadUvarint(br); err != nil {
+			return nil, err
+		}
+	}
+	for i := range entries {
+		if entries[i].length, err = binary.ReadUvarint(br); err != nil {
+			return nil, err
+		}
+	}
+	for i := range entries {
+		v, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		// 0 means straight after the entry before, so the first can't have it
+		switch {
+		case v == 0 && i == 0:
+			return nil, errors.New("corrupt directory")
+		case v == 0:
+			entries[i].offset = entries[i-1].offset + entries[i-1].length
+		default:
+			entries[i].offset = v - 1
+		}
+	}
+
+	return entries, nil
+}
+
+// findPMEntry finds the entry covering id, the last one starting at or
+// before it
+func findPMEntry(entries []pmEntry, id uint64) (pmEntry, bool) {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].tileID > id }) - 1
+	if i < 0 {
+		return pmEntry{}, false
+	}
+	entry := entries[i]
+	if entry.runLength == 0 || id-entry.tileID < entry.runLength {
+		return entry, true
+	}
+	return pmEntry{}, false
+}
+
+// pmTileID numbers tiles along a Hilbert curve through each zoom level in
+// turn, so neighbouring tiles sit near each other in the archive
+func pmTileID(z, x, y int) uint64 {
+	// every tile on the zoom levels above
+	var id uint64
+	for level := range z {
+		id += uint64(1) << (2 * level)
+	}
+
+	tx, ty := uint64(x), uint64(y)
+	for s := uint64(1) << z >> 1; s > 0; s >>= 1 {
+		var rx, ry uint64
+		if tx&s > 0 {
+			rx = 1
+		}
+		if ty&s > 0 {
+			ry = 1
+		}
+		id += s * s * ((3 * rx) ^ ry)
+
+		// rotate the quadrant so the curve joins up
+		if ry == 0 {
+			if rx == 1 {
+				tx = s - 1 - tx
+				ty = s - 1 - ty
+			}
+			tx, ty = ty, tx
+		}
+	}
+	return id
+}
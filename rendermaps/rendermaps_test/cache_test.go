@@ -0,0 +1,161 @@
+package rendermaps_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isobelmcrae/trip/rendermaps"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// bigTileServer serves 300KB tiles that stay fresh, counting requests
+func bigTileServer(t *testing.T, requests *int) *rendermaps.HTTPProvider {
+	fc := geojson.NewFeatureCollection()
+	feature := geojson.NewFeature(orb.Point{0, 0})
+	feature.Properties["name"] = strings.Repeat("x", 300<<10)
+	fc.Append(feature)
+	body, err := mvt.Marshal(mvt.Layers{mvt.NewLayer("place", fc)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write(body)
+	})
+	return rendermaps.NewHTTPProvider(server.URL)
+}
+
+func TestCacheLimitsSize(t *testing.T) {
+	t.Setenv("TRIP_TILE_CACHE_MB", "1")
+	requests := 0
+	p := bigTileServer(t, &requests)
+
+	for x := range 6 {
+		if _, err := p.Tile(3, x, 0); err != nil {
+			t.Fatal(err)
+		}
+		// far enough apart to order them
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats, err := rendermaps.TileCacheStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Bytes > 1<<20 {
+		t.Errorf("cache grew to %d bytes", stats.Bytes)
+	}
+
+	// the last tile is still cached, the first was dropped
+	requests = 0
+	p.Tile(3, 5, 0)
+	if requests != 0 {
+		t.Error("expected the most recent tile to be kept")
+	}
+	p.Tile(3, 0, 0)
+	if requests != 1 {
+		t.Error("expected the least recently used tile to be dropped")
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	requests := 0
+	p := bigTileServer(t, &requests)
+	p.Tile(3, 0, 0)
+
+	// unused for longer than the cache keeps tiles
+	files, _ := os.ReadDir(rendermaps.CacheDir())
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for _, f := range files {
+		os.Chtimes(filepath.Join(rendermaps.CacheDir(), f.Name()), old, old)
+	}
+
+	p.Tile(3, 0, 0)
+	if requests != 2 {
+		t.Errorf("expected an unused tile to expire, got %d requests", requests)
+	}
+}
+
+func TestCacheUnwritable(t *testing.T) {
+	requests := 0
+	p := bigTileServer(t, &requests)
+
+	// the cache's parent is a file, so it can't be made
+	parent := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(parent, nil, 0644)
+	t.Setenv("XDG_CACHE_HOME", parent)
+
+	for range 2 {
+		if _, err := p.Tile(3, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected tiles to be fetched each time without a cache, got %d requests", requests)
+	}
+}
+
+func TestCacheStatsSkipsTemp(t *testing.T) {
+	requests := 0
+	p := bigTileServer(t, &requests)
+	p.Tile(3, 0, 0)
+
+	// left by a write that's still going
+	files, _ := os.ReadDir(rendermaps.CacheDir())
+	os.WriteFile(filepath.Join(rendermaps.CacheDir(), files[0].Name()+".tmp123"), []byte("half a tile"), 0644)
+
+	stats, err := rendermaps.TileCacheStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 1 {
+		t.Errorf("expected only the tile to be counted, got %d files", stats.Files)
+	}
+}
+
+func TestCacheRemovesLegacyTiles(t *testing.T) {
+	requests := 0
+	p := bigTileServer(t, &requests)
+
+	// a tile from before they were keyed by server
+	legacy := filepath.Join(rendermaps.CacheDir(), "3-0-0")
+	os.MkdirAll(rendermaps.CacheDir(), 0755)
+	os.WriteFile(legacy, []byte("old tile"), 0644)
+
+	p.Tile(3, 0, 0)
+	if _, err := os.Stat(legacy); err == nil {
+		t.Error("expected the legacy tile to be removed")
+	}
+}
+
+func TestPurgeTileCache(t *testing.T) {
+	requests := 0
+	p := bigTileServer(t, &requests)
+	p.Tile(3, 0, 0)
+	p.Tile(3, 1, 0)
+
+	// not the cache's to remove
+	other := filepath.Join(rendermaps.CacheDir(), "notes.txt")
+	os.WriteFile(other, []byte("mine"), 0644)
+
+	removed, freed, err := rendermaps.PurgeTileCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 || freed < 600<<10 {
+		t.Errorf("removed %d files, %d bytes", removed, freed)
+	}
+	if stats, _ := rendermaps.TileCacheStats(); stats.Files != 0 {
+		t.Errorf("expected an empty cache, got %d files", stats.Files)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expected files that aren't tiles to be left alone: %v", err)
+	}
+}
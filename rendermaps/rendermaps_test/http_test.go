@@ -0,0 +1,181 @@
+package rendermaps_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/isobelmcrae/trip/rendermaps"
+)
+
+// tileServer serves handler, keeping the cache in a fresh directory
+func tileServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func cachedFiles(t *testing.T) int {
+	entries, _ := os.ReadDir(rendermaps.CacheDir())
+	return len(entries)
+}
+
+func TestHTTPProviderTemplate(t *testing.T) {
+	t.Setenv("TRIP_TILES_USER_AGENT", "test-agent")
+	var path, agent string
+	tile := testTile(t)
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path, agent = r.URL.Path, r.UserAgent()
+		w.Write(tile)
+	})
+
+	p := rendermaps.NewHTTPProvider(server.URL + "/tiles/{z}/{x}/{y}.mvt")
+	if _, err := p.Tile(3, 4, 5); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/tiles/3/4/5.mvt" {
+		t.Errorf("requested %s", path)
+	}
+	if agent != "test-agent" {
+		t.Errorf("sent User-Agent %q", agent)
+	}
+}
+
+func TestHTTPProviderFailuresNotCached(t *testing.T) {
+	status := http.StatusInternalServerError
+	body := []byte("error page")
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write(body)
+	})
+	p := rendermaps.NewHTTPProvider(server.URL)
+
+	if _, err := p.Tile(1, 0, 0); err == nil {
+		t.Error("expected an error for a 500")
+	}
+	status = http.StatusNotFound
+	if _, err := p.Tile(1, 0, 0); !errors.Is(err, rendermaps.ErrNoTile) {
+		t.Errorf("expected ErrNoTile for a 404, got %v", err)
+	}
+	if n := cachedFiles(t); n != 0 {
+		t.Errorf("expected failed responses not to be cached, got %d files", n)
+	}
+
+	// e.g. a captive portal's login page
+	status = http.StatusOK
+	if _, err := p.Tile(1, 0, 0); err == nil {
+		t.Error("expected an error for a 200 that isn't a tile")
+	}
+	if n := cachedFiles(t); n != 0 {
+		t.Errorf("expected a page that isn't a tile not to be cached, got %d files", n)
+	}
+
+	body = testTile(t)
+	if data, err := p.Tile(1, 0, 0); err != nil || !bytes.Equal(data, body) {
+		t.Errorf("got %q, %v", data, err)
+	}
+	if n := cachedFiles(t); n != 1 {
+		t.Errorf("expected the tile to be cached, got %d files", n)
+	}
+}
+
+func TestHTTPProviderMaxAge(t *testing.T) {
+	requests := 0
+	tile := testTile(t)
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Write(tile)
+	})
+	p := rendermaps.NewHTTPProvider(server.URL)
+
+	for range 2 {
+		if _, err := p.Tile(1, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected a fresh tile not to be fetched again, got %d requests", requests)
+	}
+}
+
+func TestHTTPProviderRevalidates(t *testing.T) {
+	var requests, notModified int
+	tile := testTile(t)
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(tile)
+	})
+	p := rendermaps.NewHTTPProvider(server.URL)
+
+	for range 2 {
+		data, err := p.Tile(1, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, tile) {
+			t.Errorf("got %q", data)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("expected the second request to revalidate, got %d requests and %d not modified", requests, notModified)
+	}
+}
+
+func TestHTTPProviderNotModifiedRefreshesExpiry(t *testing.T) {
+	requests := 0
+	tile := testTile(t)
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			// fresh for a while now
+			w.Header().Set("Cache-Control", "max-age=3600")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Write(tile)
+	})
+	p := rendermaps.NewHTTPProvider(server.URL)
+
+	for range 3 {
+		data, err := p.Tile(1, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, tile) {
+			t.Errorf("got %q", data)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected the 304's expiry to be kept, got %d requests", requests)
+	}
+}
+
+func TestHTTPProviderNoStore(t *testing.T) {
+	tile := testTile(t)
+	server := tileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(tile)
+	})
+	p := rendermaps.NewHTTPProvider(server.URL)
+
+	if _, err := p.Tile(1, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := cachedFiles(t); n != 0 {
+		t.Errorf("expected a no-store tile not to be cached, got %d files", n)
+	}
+}
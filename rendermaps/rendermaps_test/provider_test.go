@@ -0,0 +1,290 @@
+package rendermaps_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isobelmcrae/trip/rendermaps"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// testTile is a vector tile all water
+func testTile(t *testing.T) []byte {
+	fc := geojson.NewFeatureCollection()
+	fc.Append(geojson.NewFeature(orb.Polygon{{{0, 0}, {4096, 0}, {4096, 4096}, {0, 4096}, {0, 0}}}))
+	data, err := mvt.MarshalGzipped(mvt.Layers{mvt.NewLayer("water", fc)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// writeMBTiles makes an MBTiles file holding data as tile z/x/y
+func writeMBTiles(t *testing.T, z, x, y int, data []byte) string {
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE metadata (name TEXT, value TEXT);
+		CREATE TABLE tiles (zoom_level INTEGER, tile_column INTEGER, tile_row INTEGER, tile_data BLOB);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rows count up from the south
+	if _, err := db.Exec("INSERT INTO tiles VALUES (?, ?, ?, ?)", z, x, (1<<z)-1-y, data); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMBTiles(t *testing.T) {
+	path := writeMBTiles(t, 1, 1, 0, []byte("north east"))
+
+	p, err := rendermaps.OpenMBTiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	data, err := p.Tile(1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "north east" {
+		t.Errorf("got tile %q", data)
+	}
+
+	if _, err := p.Tile(1, 1, 1); !errors.Is(err, rendermaps.ErrNoTile) {
+		t.Errorf("expected ErrNoTile for a missing tile, got %v", err)
+	}
+}
+
+func TestMBTilesAwkwardPath(t *testing.T) {
+	// characters that mean something in a URI
+	path := filepath.Join(t.TempDir(), "tiles?#%20.mbtiles")
+	if err := os.Rename(writeMBTiles(t, 1, 1, 0, []byte("north east")), path); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := rendermaps.OpenMBTiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if data, err := p.Tile(1, 1, 0); err != nil || string(data) != "north east" {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+func TestMBTilesRelativePath(t *testing.T) {
+	path := writeMBTiles(t, 1, 1, 0, []byte("north east"))
+	t.Chdir(filepath.Dir(path))
+
+	p, err := rendermaps.OpenMBTiles(filepath.Base(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if data, err := p.Tile(1, 1, 0); err != nil || string(data) != "north east" {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+type pmEntry struct {
+	id, offset, length, runLength uint64
+}
+
+func pmDirectory(entries []pmEntry) []byte {
+	var dir []byte
+	dir = binary.AppendUvarint(dir, uint64(len(entries)))
+	last := uint64(0)
+	for _, e := range entries {
+		dir = binary.AppendUvarint(dir, e.id-last)
+		last = e.id
+	}
+	for _, e := range entries {
+		dir = binary.AppendUvarint(dir, e.runLength)
+	}
+	for _, e := range entries {
+		dir = binary.AppendUvarint(dir, e.length)
+	}
+	for i, e := range entries {
+		if i > 0 && e.offset == entries[i-1].offset+entries[i-1].length {
+			dir = binary.AppendUvarint(dir, 0)
+		} else {
+			dir = binary.AppendUvarint(dir, e.offset+1)
+		}
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(dir)
+	gz.Close()
+	return buf.Bytes()
+}
+
+// writePMTiles makes a PMTiles archive of tiles by their id, indexed
+// through a leaf directory
+func writePMTiles(t *testing.T, tiles map[uint64][]byte, ids []uint64) string {
+	var data []byte
+	var entries []pmEntry
+	for _, id := range ids {
+		entries = append(entries, pmEntry{id: id, offset: uint64(len(data)), length: uint64(len(tiles[id])), runLength: 1})
+		data = append(data, tiles[id]...)
+	}
+	return writePMArchive(t, pmDirectory(entries), data)
+}
+
+// writePMArchive makes a PMTiles archive with one leaf directory
+func writePMArchive(t *testing.T, leaf []byte, data []byte) string {
+	root := pmDirectory([]pmEntry{{id: 0, offset: 0, length: uint64(len(leaf))}})
+
+	header := make([]byte, 127)
+	copy(header, "PMTiles")
+	header[7] = 3
+	put := func(at int, v int) { binary.LittleEndian.PutUint64(header[at:], uint64(v)) }
+	put(8, 127)                      // root directory
+	put(16, len(root))               //
+	put(40, 127+len(root))           // leaf directories
+	put(48, len(leaf))               //
+	put(56, 127+len(root)+len(leaf)) // tile data
+	put(64, len(data))               //
+	header[97] = 2                   // gzipped directories
+	header[98] = 1                   // uncompressed tiles
+	header[99] = 1                   // vector tiles
+
+	path := filepath.Join(t.TempDir(), "test.pmtiles")
+	archive := append(append(append(header, root...), leaf...), data...)
+	if err := os.WriteFile(path, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPMTiles(t *testing.T) {
+	// zoom 1's tiles are numbered 1 to 4 along the curve: 0/0, 0/1, 1/1, 1/0
+	path := writePMTiles(t, map[uint64][]byte{
+		0: []byte("world"),
+		1: []byte("north west"),
+		4: []byte("north east"),
+	}, []uint64{0, 1, 4})
+
+	p, err := rendermaps.OpenPMTiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for _, c := range []struct {
+		z, x, y int
+		want    string
+	}{
+		{0, 0, 0, "world"},
+		{1, 0, 0, "north west"},
+		{1, 1, 0, "north east"},
+	} {
+		data, err := p.Tile(c.z, c.x, c.y)
+		if err != nil {
+			t.Fatalf("%d/%d/%d: %v", c.z, c.x, c.y, err)
+		}
+		if string(data) != c.want {
+			t.Errorf("%d/%d/%d: got %q, want %q", c.z, c.x, c.y, data, c.want)
+		}
+	}
+
+	if _, err := p.Tile(1, 1, 1); !errors.Is(err, rendermaps.ErrNoTile) {
+		t.Errorf("expected ErrNoTile for a missing tile, got %v", err)
+	}
+}
+
+func TestPMTilesCorrupt(t *testing.T) {
+	// a tile running past the end of the tile data
+	path := writePMArchive(t, pmDirectory([]pmEntry{{id: 0, offset: 2, length: 5, runLength: 1}}), []byte("world"))
+	p, err := rendermaps.OpenPMTiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, err := p.Tile(0, 0, 0); err == nil || errors.Is(err, rendermaps.ErrNoTile) {
+		t.Errorf("expected an error for a tile outside the data, got %v", err)
+	}
+
+	// the first entry can't follow on from the one before
+	var dir []byte
+	for _, v := range []uint64{1, 0, 1, 5, 0} { // count, id, run length, length, offset
+		dir = binary.AppendUvarint(dir, v)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(dir)
+	gz.Close()
+
+	path = writePMArchive(t, buf.Bytes(), []byte("world"))
+	p, err = rendermaps.OpenPMTiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, err := p.Tile(0, 0, 0); err == nil || errors.Is(err, rendermaps.ErrNoTile) {
+		t.Errorf("expected an error for a corrupt directory, got %v", err)
+	}
+}
+
+func TestOpenTileProvider(t *testing.T) {
+	if _, err := rendermaps.OpenTileProvider(filepath.Join(t.TempDir(), "missing.pmtiles")); err == nil {
+		t.Error("expected an error for a missing archive")
+	}
+	if _, ok := mustOpen(t, "").(*rendermaps.HTTPProvider); !ok {
+		t.Error("expected the tile server by default")
+	}
+	if _, ok := mustOpen(t, writeMBTiles(t, 0, 0, 0, nil)).(*rendermaps.MBTilesProvider); !ok {
+		t.Error("expected an MBTiles provider for a .mbtiles file")
+	}
+}
+
+func mustOpen(t *testing.T, source string) rendermaps.TileProvider {
+	p, err := rendermaps.OpenTileProvider(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// with tiles from a file, maps render without the network
+func TestRenderOffline(t *testing.T) {
+	p, err := rendermaps.OpenMBTiles(writeMBTiles(t, 14, 15073, 9832, testTile(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// the middle of that tile, by Central
+	render := func() string { return rendermaps.RenderMapOneshot(20, 5, -33.8818, 151.2142, 14) }
+
+	rendermaps.SetTileProvider(emptyProvider{})
+	blank := render()
+	rendermaps.SetTileProvider(p)
+	if render() == blank {
+		t.Error("expected the tile's water to be drawn")
+	}
+}
+
+type emptyProvider struct{}
+
+func (emptyProvider) Tile(z, x, y int) ([]byte, error) {
+	return nil, rendermaps.ErrNoTile
+}
@@ -0,0 +1,117 @@
+package rendermaps_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isobelmcrae/trip/rendermaps"
+)
+
+// slowProvider counts fetches of each tile, taking long enough that
+// renders overlap
+type slowProvider struct {
+	tile    []byte
+	mu      sync.Mutex
+	fetches map[[3]int]int
+	total   atomic.Int32
+}
+
+func (p *slowProvider) Tile(z, x, y int) ([]byte, error) {
+	time.Sleep(20 * time.Millisecond)
+	p.mu.Lock()
+	p.fetches[[3]int{z, x, y}]++
+	p.mu.Unlock()
+	p.total.Add(1)
+	return p.tile, nil
+}
+
+func TestConcurrentRendersFetchOnce(t *testing.T) {
+	p := &slowProvider{tile: testTile(t), fetches: map[[3]int]int{}}
+	rendermaps.SetTileProvider(p)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rendermaps.RenderMapOneshot(80, 24, -33.8818, 151.2142, 14)
+		}()
+	}
+	wg.Wait()
+
+	for tile, n := range p.fetches {
+		if n != 1 {
+			t.Errorf("tile %v fetched %d times", tile, n)
+		}
+	}
+
+	// and later renders reuse the decoded tiles
+	before := p.total.Load()
+	rendermaps.RenderMapOneshot(80, 24, -33.8818, 151.2142, 14)
+	if p.total.Load() != before {
+		t.Error("expected tiles to be kept in memory")
+	}
+}
+
+func TestBrokenTileFetchedOnce(t *testing.T) {
+	p := &slowProvider{tile: []byte("not a tile"), fetches: map[[3]int]int{}}
+	rendermaps.SetTileProvider(p)
+
+	rendermaps.RenderMapOneshot(80, 24, -33.8818, 151.2142, 14)
+	before := p.total.Load()
+	rendermaps.RenderMapOneshot(80, 24, -33.8818, 151.2142, 14)
+	if p.total.Load() != before {
+		t.Error("expected tiles that don't decode to be kept in memory, empty")
+	}
+}
+
+// countingProvider records the most tiles being fetched at once
+type countingProvider struct {
+	tile              []byte
+	mu                sync.Mutex
+	active, maxActive int
+	total             int
+}
+
+func (p *countingProvider) Tile(z, x, y int) ([]byte, error) {
+	p.mu.Lock()
+	p.active++
+	p.total++
+	p.maxActive = max(p.maxActive, p.active)
+	p.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	return p.tile, nil
+}
+
+func TestPrefetch(t *testing.T) {
+	p := &countingProvider{tile: testTile(t)}
+	rendermaps.SetTileProvider(p)
+
+	views := []rendermaps.MapView{
+		{Lat: -33.8818, Lon: 151.2142, Zoom: 14},
+		{Lat: -33.8610, Lon: 151.2110, Zoom: 13},
+	}
+	rendermaps.Prefetch(80, 24, views)
+
+	if p.total == 0 {
+		t.Fatal("expected tiles to be prefetched")
+	}
+	if p.maxActive > 4 {
+		t.Errorf("fetched %d tiles at once", p.maxActive)
+	}
+
+	fetched := p.total
+	for _, v := range views {
+		rendermaps.RenderMapOneshot(80, 24, v.Lat, v.Lon, v.Zoom)
+	}
+	if p.total != fetched {
+		t.Errorf("expected prefetched views to draw without fetching, fetched %d more", p.total-fetched)
+	}
+}
@@ -3,19 +3,19 @@ package rendermaps
 import (
 	"bytes"
 	"compress/gzip"
+	"container/list"
 	"fmt"
 	"io"
 	"log"
 	"math"
-	"net/http"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/mattn/go-runewidth"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/encoding/mvt"
 	"github.com/tidwall/rtree"
+	"golang.org/x/sync/singleflight"
 
 	_ "embed"
 )
@@ -34,21 +34,36 @@ type Tile struct {
 	Rtree *rtree.RTree
 }
 
+// TileSource loads and decodes tiles from its provider. Tiles are never
+// changed once loaded, so every render and every SSH session shares them
 type TileSource struct {
-	url        string
-	styler     *Styler
-	client     *http.Client
-	cache      map[string]*Tile
-	colorCache map[string]string
-	mu         sync.Mutex
+	provider TileProvider
+	styler   *Styler
+
+	// decoded tiles, least recently used dropped first once full
+	cache    map[string]*list.Element
+	lru      *list.List
+	maxTiles int
+	mu       sync.Mutex
+
+	// a tile wanted by several renders at once is only fetched once
+	fetches singleflight.Group
 }
 
+// cachedTile is an element of TileSource.lru
+type cachedTile struct {
+	key  string
+	tile *Tile
+}
+
+// how many decoded tiles are kept in memory, a few full screen maps' worth
+const maxMemoryTiles = 512
+
 //go:embed style.json
 var styleJson []byte
 
 var (
 	gStyler = makeNewStyler()
-	gTs = NewTileSource(TileSourceURL, gStyler)
 )
 
 func makeNewStyler() (*Styler) {
@@ -59,75 +74,86 @@ func makeNewStyler() (*Styler) {
 	return styler
 }
 
-// TODO migrate to cache directory
-
-func NewTileSource(url string, styler *Styler) *TileSource {
+func NewTileSource(provider TileProvider, styler *Styler) *TileSource {
 	return &TileSource{
-		url: url, client: &http.Client{Timeout: 10 * time.Second}, styler: styler,
-		cache: make(map[string]*Tile), colorCache: make(map[string]string),
+		provider: provider, styler: styler,
+		cache: make(map[string]*list.Element), lru: list.New(), maxTiles: maxMemoryTiles,
 	}
 }
 
 func (ts *TileSource) GetTile(z, x, y int) (*Tile, error) {
-	var err error
-	var tile *Tile
-	var ok bool
-	
 	key := fmt.Sprintf("%d-%d-%d", z, x, y)
+	if tile, ok := ts.cached(key); ok {
+		return tile, nil
+	}
 
-	//bench := time.Now()
-	ts.mu.Lock()
-	if tile, ok = ts.cache[key]; ok {
-		ts.mu.Unlock()
-		// cached
-	} else {
-		ts.mu.Unlock()
-		var body []byte
-
-		if body, err = cacheGetKey(key); err == nil {
-			// cached
-		} else {
-			url := fmt.Sprintf("%s%d/%d/%d.pbf", ts.url, z, x, y)
-			req, _ := http.NewRequest("GET", url, nil)
-			req.Header.Set("User-Agent", "MapSCII-Go-MVP/1.0")
-			resp, err := ts.client.Do(req)
-			if err != nil {
-				return nil, err
-			}
-			defer resp.Body.Close()
-			body, err = io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			cacheInsertKey(key, body) // cache the response
+	v, err, _ := ts.fetches.Do(key, func() (any, error) {
+		// it may have been loaded while we were waiting to get here
+		if tile, ok := ts.cached(key); ok {
+			return tile, nil
+		}
+
+		body, err := ts.provider.Tile(z, x, y)
+		if err != nil {
+			return nil, err
 		}
 
-		ts.mu.Lock()
-		tile = &Tile{Rtree: &rtree.RTree{}}
-		if err := tile.Load(body, ts.styler, ts.colorCache); err != nil {
-			return &Tile{Rtree: &rtree.RTree{}}, nil
+		tile := &Tile{Rtree: &rtree.RTree{}}
+		if err := tile.Load(body, ts.styler, make(map[string]string)); err != nil {
+			// kept empty, so a broken tile isn't fetched and decoded again
+			// on every frame
+			log.Printf("Failed to decode tile %d/%d/%d: %v", z, x, y, err)
+			tile = &Tile{Rtree: &rtree.RTree{}}
 		}
+		ts.store(key, tile)
+		return tile, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Tile), nil
+}
+
+func (ts *TileSource) cached(key string) (*Tile, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
 
-		ts.cache[key] = tile
-		ts.mu.Unlock()
+	if el, ok := ts.cache[key]; ok {
+		ts.lru.MoveToFront(el)
+		return el.Value.(*cachedTile).tile, true
 	}
+	return nil, false
+}
 
-	return tile, nil
+func (ts *TileSource) store(key string, tile *Tile) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.cache[key] = ts.lru.PushFront(&cachedTile{key, tile})
+	for ts.lru.Len() > ts.maxTiles {
+		oldest := ts.lru.Back()
+		ts.lru.Remove(oldest)
+		delete(ts.cache, oldest.Value.(*cachedTile).key)
+	}
 }
 
-func (t *Tile) Load(buffer []byte, styler *Styler, colorCache map[string]string) error {
+// decodeTile reads a vector tile's layers, unzipping it first if need be
+func decodeTile(buffer []byte) (mvt.Layers, error) {
 	gz, err := gzip.NewReader(bytes.NewReader(buffer))
-	var data []byte
-	if err == nil {
-		data, err = io.ReadAll(gz)
-		gz.Close()
-	} else {
-		data = buffer
+	if err != nil {
+		// not gzipped
+		return mvt.Unmarshal(buffer)
 	}
+	defer gz.Close()
+	data, err := io.ReadAll(gz)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	layers, err := mvt.Unmarshal(data)
+	return mvt.Unmarshal(data)
+}
+
+func (t *Tile) Load(buffer []byte, styler *Styler, colorCache map[string]string) error {
+	layers, err := decodeTile(buffer)
 	if err != nil {
 		return err
 	}
@@ -179,6 +205,21 @@ func NewLabelBuffer() *LabelBuffer {
 	return &LabelBuffer{tree: &rtree.RTree{}}
 }
 
+// reserve marks a spot as taken whatever is already there
+func (lb *LabelBuffer) reserve(x, y, width int) {
+	lb.tree.Insert([2]float64{float64(x), float64(y)}, [2]float64{float64(x + width), float64(y)}, nil)
+}
+
+// isFree reports whether no label overlaps the box
+func (lb *LabelBuffer) isFree(x0, y0, x1, y1 int) bool {
+	free := true
+	lb.tree.Search([2]float64{float64(x0), float64(y0)}, [2]float64{float64(x1), float64(y1)}, func(_, _ [2]float64, _ interface{}) bool {
+		free = false
+		return false
+	})
+	return free
+}
+
 func (lb *LabelBuffer) WriteIfPossible(text string, x, y int) bool {
 	width := runewidth.StringWidth(text)
 	bounds := [2][2]float64{{float64(x - 1), float64(y - 1)}, {float64(x + width + 1), float64(y + 1)}}
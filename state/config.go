@@ -0,0 +1,152 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ConfigDir is where per-user files such as favourites live,
+// $XDG_CONFIG_HOME/trip falling back to ~/.config/trip
+func ConfigDir() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "trip")
+}
+
+// ExportDir is where exported journeys are written: $XDG_DOWNLOAD_DIR,
+// then the download directory in user-dirs.dirs, then ~/Downloads if there
+// is one, falling back to the current directory
+func ExportDir() string {
+	if dir := os.Getenv("XDG_DOWNLOAD_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	if dir := userDirsDownload(home); dir != "" {
+		return dir
+	}
+	downloads := filepath.Join(home, "Downloads")
+	if info, err := os.Stat(downloads); err == nil && info.IsDir() {
+		return downloads
+	}
+	return "."
+}
+
+// userDirsDownload reads the download directory from xdg-user-dirs' config,
+// where desktops usually set it rather than in the environment
+// https://www.freedesktop.org/wiki/Software/xdg-user-dirs/
+func userDirsDownload(home string) string {
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		config = filepath.Join(home, ".config")
+	}
+	data, err := os.ReadFile(filepath.Join(config, "user-dirs.dirs"))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "XDG_DOWNLOAD_DIR=")
+		if !ok {
+			continue
+		}
+		// "$HOME/Downloads" or an absolute path
+		value = strings.Trim(value, `"`)
+		if rest, ok := strings.CutPrefix(value, "$HOME"); ok {
+			value = home + rest
+		}
+		// set to home when the user doesn't want one
+		if !filepath.IsAbs(value) || filepath.Clean(value) == filepath.Clean(home) {
+			return ""
+		}
+		return value
+	}
+	return ""
+}
+
+// SSHUserDir keeps each SSH user's files apart, keyed by their public key
+func SSHUserDir(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return filepath.Join(ConfigDir(), "ssh", hex.EncodeToString(sum[:]))
+}
+
+// one store per path, so SSH sessions sharing a key don't overwrite each other
+type storeCache[T any] struct {
+	stores map[string]*T
+	mu     sync.Mutex
+}
+
+func (c *storeCache[T]) get(path string, load func(string) (*T, error)) (*T, error) {
+	// nowhere to save, nothing to share
+	if path == "" {
+		return load(path)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if store, ok := c.stores[path]; ok {
+		return store, nil
+	}
+	// kept even if it failed to load, so every session refuses to save
+	// over an unreadable file, or shares the fresh store that replaced it
+	store, err := load(path)
+	if c.stores == nil {
+		c.stores = make(map[string]*T)
+	}
+	c.stores[path] = store
+	return store, err
+}
+
+// setAside moves a store that can't be decoded to path.bak, so the empty
+// store replacing it can be saved without losing anything. If it can't be
+// moved, saveErr is set and should be returned on every save instead
+func setAside(path string, decodeErr error) (saveErr error, err error) {
+	aside := path + ".bak"
+	if renameErr := os.Rename(path, aside); renameErr != nil {
+		err := fmt.Errorf("%s is unreadable, not saving over it: %w", path, decodeErr)
+		return err, err
+	}
+	return nil, fmt.Errorf("%s was unreadable, moved it to %s: %w", path, aside, decodeErr)
+}
+
+// writeFileAtomic writes to a temporary file and renames it over path, so a
+// crash halfway through never leaves a truncated file behind
+func writeFileAtomic(path string, data []byte) error {
+	// an ephemeral store, e.g. an SSH user without a key
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,163 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// a stop the user has given a name, e.g. "home"
+type FavouriteStop struct {
+	Name     string `json:"name"`
+	StopID   string `json:"stopId"`
+	StopName string `json:"stopName"`
+}
+
+// an origin→destination pair the user plans often
+type SavedTrip struct {
+	Name            string `json:"name"`
+	OriginID        string `json:"originId"`
+	OriginName      string `json:"originName"`
+	DestinationID   string `json:"destinationId"`
+	DestinationName string `json:"destinationName"`
+}
+
+// Favourites is a small JSON backed store, every change is written straight
+// back to disk
+type Favourites struct {
+	Stops []FavouriteStop `json:"stops"`
+	Trips []SavedTrip     `json:"trips"`
+
+	path string
+	mu   sync.Mutex
+
+	// set when the file on disk couldn't be read, so it isn't saved over
+	saveErr error
+
+	// bumped on every change, see Version
+	version int
+}
+
+var favouritesStores storeCache[Favourites]
+
+// OpenFavourites returns the favourites kept in userDir. An empty userDir
+// gives a store that is never written to disk
+func OpenFavourites(userDir string) (*Favourites, error) {
+	var path string
+	if userDir != "" {
+		path = filepath.Join(userDir, "favourites.json")
+	}
+	return favouritesStores.get(path, loadFavourites)
+}
+
+// loadFavourites reads the store at path, a missing file is an empty store
+func loadFavourites(path string) (*Favourites, error) {
+	f := &Favourites{path: path}
+	if path == "" {
+		return f, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		f.saveErr = err
+		return f, err
+	}
+
+	if err := json.Unmarshal(data, f); err != nil {
+		// start again rather than keep whatever was partly decoded
+		f = &Favourites{path: path}
+		f.saveErr, err = setAside(path, err)
+		return f, err
+	}
+	return f, nil
+}
+
+func (f *Favourites) IsEmpty() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.Stops) == 0 && len(f.Trips) == 0
+}
+
+// List returns copies of the saved trips and stops
+func (f *Favourites) List() ([]SavedTrip, []FavouriteStop) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Trips), slices.Clone(f.Stops)
+}
+
+// AddStop saves a stop under name, replacing any favourite with the same name
+func (f *Favourites) AddStop(stop FavouriteStop) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Stops = slices.DeleteFunc(f.Stops, func(s FavouriteStop) bool { return s.Name == stop.Name })
+	f.Stops = append(f.Stops, stop)
+	return f.save()
+}
+
+// AddTrip saves a trip under name, replacing any trip with the same name
+func (f *Favourites) AddTrip(trip SavedTrip) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Trips = slices.DeleteFunc(f.Trips, func(t SavedTrip) bool { return t.Name == trip.Name })
+	f.Trips = append(f.Trips, trip)
+	return f.save()
+}
+
+func (f *Favourites) RemoveStop(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Stops = slices.DeleteFunc(f.Stops, func(s FavouriteStop) bool { return s.Name == name })
+	return f.save()
+}
+
+func (f *Favourites) RemoveTrip(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Trips = slices.DeleteFunc(f.Trips, func(t SavedTrip) bool { return t.Name == name })
+	return f.save()
+}
+
+// FindStop looks up a favourite stop by its name
+func (f *Favourites) FindStop(name string) (FavouriteStop, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, s := range f.Stops {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return FavouriteStop{}, false
+}
+
+// Version changes whenever the favourites do, so a list of them knows when
+// to reload
+func (f *Favourites) Version() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.version
+}
+
+// must be called with mu held, after every change
+func (f *Favourites) save() error {
+	f.version++
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.path, data)
+}
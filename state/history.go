@@ -0,0 +1,123 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// how many trips and searches are remembered
+const HistoryMaxEntries = 20
+
+type HistoryTrip struct {
+	OriginID        string    `json:"originId"`
+	OriginName      string    `json:"originName"`
+	DestinationID   string    `json:"destinationId"`
+	DestinationName string    `json:"destinationName"`
+	At              time.Time `json:"at"`
+}
+
+type HistorySearch struct {
+	Query string    `json:"query"`
+	At    time.Time `json:"at"`
+}
+
+// History remembers recently planned trips and stop searches, newest first
+type History struct {
+	Trips    []HistoryTrip   `json:"trips"`
+	Searches []HistorySearch `json:"searches"`
+
+	path string
+	mu   sync.Mutex
+
+	// set when the file on disk couldn't be read, so it isn't saved over
+	saveErr error
+}
+
+var historyStores storeCache[History]
+
+// OpenHistory returns the history kept in userDir. An empty userDir
+// gives a store that is never written to disk
+func OpenHistory(userDir string) (*History, error) {
+	var path string
+	if userDir != "" {
+		path = filepath.Join(userDir, "history.json")
+	}
+	return historyStores.get(path, loadHistory)
+}
+
+func loadHistory(path string) (*History, error) {
+	h := &History{path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		h.saveErr = err
+		return h, err
+	}
+
+	if err := json.Unmarshal(data, h); err != nil {
+		// start again rather than keep whatever was partly decoded
+		h = &History{path: path}
+		h.saveErr, err = setAside(path, err)
+		return h, err
+	}
+	return h, nil
+}
+
+// List returns copies of the recent trips and searches, newest first
+func (h *History) List() ([]HistoryTrip, []HistorySearch) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.Trips), slices.Clone(h.Searches)
+}
+
+// AddTrip moves the trip to the front of the history
+func (h *History) AddTrip(trip HistoryTrip) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.Trips = slices.DeleteFunc(h.Trips, func(t HistoryTrip) bool {
+		return t.OriginID == trip.OriginID && t.DestinationID == trip.DestinationID
+	})
+	h.Trips = append([]HistoryTrip{trip}, h.Trips...)
+	if len(h.Trips) > HistoryMaxEntries {
+		h.Trips = h.Trips[:HistoryMaxEntries]
+	}
+	return h.save()
+}
+
+// AddSearch moves the query to the front of the history
+func (h *History) AddSearch(search HistorySearch) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.Searches = slices.DeleteFunc(h.Searches, func(s HistorySearch) bool { return s.Query == search.Query })
+	h.Searches = append([]HistorySearch{search}, h.Searches...)
+	if len(h.Searches) > HistoryMaxEntries {
+		h.Searches = h.Searches[:HistoryMaxEntries]
+	}
+	return h.save()
+}
+
+// must be called with mu held
+func (h *History) save() error {
+	if h.saveErr != nil {
+		return h.saveErr
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(h.path, data)
+}
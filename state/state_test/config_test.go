@@ -0,0 +1,39 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isobelmcrae/trip/state"
+)
+
+func TestExportDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DOWNLOAD_DIR", "")
+
+	if dir := state.ExportDir(); dir != "." {
+		t.Errorf("expected the current directory without a download directory, got %s", dir)
+	}
+
+	downloads := filepath.Join(home, "Downloads")
+	os.Mkdir(downloads, 0755)
+	if dir := state.ExportDir(); dir != downloads {
+		t.Errorf("expected %s, got %s", downloads, dir)
+	}
+
+	// as xdg-user-dirs writes it
+	os.Mkdir(filepath.Join(home, ".config"), 0755)
+	userDirs := "# written by xdg-user-dirs-update\nXDG_DESKTOP_DIR=\"$HOME/Desktop\"\nXDG_DOWNLOAD_DIR=\"$HOME/Stuff\"\n"
+	os.WriteFile(filepath.Join(home, ".config", "user-dirs.dirs"), []byte(userDirs), 0644)
+	if dir, want := state.ExportDir(), filepath.Join(home, "Stuff"); dir != want {
+		t.Errorf("expected %s, got %s", want, dir)
+	}
+
+	t.Setenv("XDG_DOWNLOAD_DIR", "/tmp/elsewhere")
+	if dir := state.ExportDir(); dir != "/tmp/elsewhere" {
+		t.Errorf("expected the environment to win, got %s", dir)
+	}
+}
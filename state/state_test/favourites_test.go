@@ -0,0 +1,121 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isobelmcrae/trip/state"
+)
+
+func TestFavouritesSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	f, err := state.OpenFavourites(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.IsEmpty() {
+		t.Error("expected no favourites without a file")
+	}
+
+	if err := f.AddStop(state.FavouriteStop{Name: "home", StopID: "200060", StopName: "Central Station"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddTrip(state.SavedTrip{Name: "work", OriginID: "200060", DestinationID: "200020"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "favourites.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Fatal("expected favourites.json to be written")
+	}
+
+	// a new directory's store isn't shared, so copy the file there to
+	// read it back fresh
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "favourites.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := state.OpenFavourites(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trips, stops := loaded.List()
+	if len(stops) != 1 || stops[0].StopID != "200060" {
+		t.Errorf("got stops %v", stops)
+	}
+	if len(trips) != 1 || trips[0].Name != "work" {
+		t.Errorf("got trips %v", trips)
+	}
+}
+
+func TestFavouritesCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "favourites.json")
+	corrupt := []byte(`{"stops": [{"name": "home"`)
+	if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := state.OpenFavourites(dir)
+	if err == nil {
+		t.Error("expected an error for a corrupt file")
+	}
+	if !f.IsEmpty() {
+		t.Error("expected a corrupt file to give no favourites")
+	}
+
+	// saving doesn't lose the old file
+	if err := f.AddStop(state.FavouriteStop{Name: "work", StopID: "200020"}); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(kept) != string(corrupt) {
+		t.Errorf("expected the corrupt file to be kept, got %q", kept)
+	}
+}
+
+func TestFavouritesCorruptNotSaved(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "favourites.json")
+	corrupt := []byte(`not json`)
+	if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// somewhere the file can't be moved to
+	if err := os.MkdirAll(filepath.Join(path+".bak", "full"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := state.OpenFavourites(dir)
+	if err := f.AddStop(state.FavouriteStop{Name: "work", StopID: "200020"}); err == nil {
+		t.Error("expected saving over an unreadable file to fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(corrupt) {
+		t.Errorf("expected the file to be left alone, got %q", data)
+	}
+}
+
+func TestFavouritesVersion(t *testing.T) {
+	f, err := state.OpenFavourites(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddTrip(state.SavedTrip{Name: "work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// swapping one trip for another keeps the count the same
+	before := f.Version()
+	f.RemoveTrip("work")
+	f.AddTrip(state.SavedTrip{Name: "gym"})
+	if f.Version() == before {
+		t.Error("expected the version to change with the favourites")
+	}
+}
@@ -0,0 +1,53 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isobelmcrae/trip/state"
+)
+
+func TestHistoryNewestFirst(t *testing.T) {
+	h, err := state.OpenHistory(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"central", "town hall", "central"} {
+		if err := h.AddSearch(state.HistorySearch{Query: query, At: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, searches := h.List()
+	if len(searches) != 2 || searches[0].Query != "central" || searches[1].Query != "town hall" {
+		t.Errorf("got searches %v", searches)
+	}
+}
+
+func TestHistoryCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.json")
+	corrupt := []byte(`{"trips": [`)
+	if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := state.OpenHistory(dir)
+	if err == nil {
+		t.Error("expected an error for a corrupt file")
+	}
+
+	if err := h.AddTrip(state.HistoryTrip{OriginID: "200060", DestinationID: "200020", At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(kept) != string(corrupt) {
+		t.Errorf("expected the corrupt file to be kept, got %q", kept)
+	}
+}
@@ -13,6 +13,9 @@ var (
     Prompt = lg.NewStyle().
         Bold(true).
         Align(lg.Center)
+    // why a prompt's answer wasn't accepted
+    PromptError = lg.NewStyle().
+        Foreground(lg.ANSIColor(1))
     WelcomeMain = lg.NewStyle().
         AlignHorizontal(lg.Center).
         AlignVertical(lg.Center).
@@ -0,0 +1,29 @@
+package styles
+
+import (
+    "strings"
+
+    "github.com/isobelmcrae/trip/api"
+)
+
+var ModeIcons = map[api.Mode]string{
+    api.ModeTrain:     "🚆",
+    api.ModeMetro:     "🚇",
+    api.ModeLightRail: "🚊",
+    api.ModeBus:       "🚌",
+    api.ModeCoach:     "🚍",
+    api.ModeFerry:     "⛴",
+}
+
+const WheelchairIcon = "♿"
+
+// icons for every mode serving a stop, in a stable order
+func IconsForModes(modes api.Mode) string {
+    var icons []string
+    for _, mode := range api.AllModes {
+        if modes.Has(mode) {
+            icons = append(icons, ModeIcons[mode])
+        }
+    }
+    return strings.Join(icons, " ")
+}
@@ -11,8 +11,39 @@ var (
     RouteLegBox = lg.NewStyle().
         BorderStyle(lg.RoundedBorder()).
         Align(lg.Left)
+    // a time that moved on the last realtime refresh
+    ChangedTime = lg.NewStyle().
+        Foreground(lg.ANSIColor(3)).
+        Bold(true)
 )
 
+// realtime badges, delays are coloured by how late the service is
+var (
+    OnTime = lg.NewStyle().
+        Foreground(lg.ANSIColor(2))
+    ScheduledOnly = lg.NewStyle().
+        Faint(true)
+    Cancelled = lg.NewStyle().
+        Foreground(lg.ANSIColor(1)).
+        Bold(true)
+    Struck = lg.NewStyle().
+        Strikethrough(true)
+)
+
+// DelayStyle colours a delay in minutes, yellow for a few minutes, orange
+// from 5 and red from 10
+func DelayStyle(minutes int) lg.Style {
+    switch {
+    case minutes >= 10:
+        return lg.NewStyle().Foreground(lg.ANSIColor(1)).Bold(true)
+    case minutes >= 5:
+        return lg.NewStyle().Foreground(lg.Color("208"))
+    case minutes > 0:
+        return lg.NewStyle().Foreground(lg.ANSIColor(3))
+    }
+    return OnTime
+}
+
 func CreateLineHighlight(transit string) lg.Style {
     colour := LgColourForLine(transit)
 
@@ -0,0 +1,323 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/76creates/stickers/flexbox"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
+	"github.com/isobelmcrae/trip/styles"
+)
+
+type compareKeymap struct {
+	Sort        key.Binding
+	ExcludeMode key.Binding
+	MaxChanges  key.Binding
+	Select      key.Binding
+}
+
+// the table's own keys move the cursor, see table.DefaultKeyMap
+var compareKeymapDefault = compareKeymap{
+	Sort:        key.NewBinding(key.WithKeys("s")),
+	ExcludeMode: key.NewBinding(key.WithKeys("x")),
+	MaxChanges:  key.NewBinding(key.WithKeys("c")),
+	Select:      key.NewBinding(key.WithKeys("enter")),
+}
+
+// a way of ordering journeys, least first
+type journeySort struct {
+	name string
+	less func(a, b api.Journey) int
+}
+
+var journeySorts = []journeySort{
+	{"departure", func(a, b api.Journey) int { return compareTimes(a.Departure, b.Departure) }},
+	{"arrival", func(a, b api.Journey) int { return compareTimes(a.Arrival, b.Arrival) }},
+	{"duration", func(a, b api.Journey) int {
+		da, _ := format.JourneyMinutes(a)
+		db, _ := format.JourneyMinutes(b)
+		return cmp.Compare(da, db)
+	}},
+	{"changes", func(a, b api.Journey) int { return cmp.Compare(a.Changes(), b.Changes()) }},
+	{"walking", func(a, b api.Journey) int { return cmp.Compare(a.WalkingTime(), b.WalkingTime()) }},
+	{"fare", func(a, b api.Journey) int {
+		// journeys without a fare go last
+		fa, okA := a.AdultFare()
+		fb, okB := b.AdultFare()
+		if okA != okB {
+			if okA {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(fa, fb)
+	}},
+}
+
+func compareTimes(a func() (time.Time, bool), b func() (time.Time, bool)) int {
+	ta, _ := a()
+	tb, _ := b()
+	return ta.Compare(tb)
+}
+
+// -1 for any number of changes
+var maxChangesFilters = []int{-1, 0, 1, 2}
+
+// compareState lays every journey out in a table, taking over the map so
+// there's room for the columns. Selecting one goes back to its details
+type compareState struct {
+	root  *RootModel
+	route *routeState
+	table table.Model
+
+	sort        int // index into journeySorts
+	excludeMode int // index into stopModeFilters, ModeAny excluding nothing
+	maxChanges  int // index into maxChangesFilters
+
+	shown     []int    // indices into route.Routes, in table order
+	shownKeys []string // the shown journeys' journeyKey, as the indices move on a refresh
+}
+
+func newCompareState(root *RootModel, route *routeState) AppState {
+	s := &compareState{
+		root:  root,
+		route: route,
+	}
+
+	s.table = table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Depart", Width: 8},
+			{Title: "Arrive", Width: 8},
+			{Title: "Time", Width: 6},
+			{Title: "Changes", Width: 7},
+			{Title: "Walk", Width: 6},
+			{Title: "Fare", Width: 7},
+			{Title: "Modes", Width: 14},
+		}),
+		table.WithFocused(true),
+	)
+
+	tableStyles := table.DefaultStyles()
+	tableStyles.Header = tableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Bold(true)
+	tableStyles.Selected = tableStyles.Selected.
+		Foreground(lipgloss.NoColor{}).
+		Background(lipgloss.NoColor{}).
+		Reverse(true)
+	s.table.SetStyles(tableStyles)
+
+	s.resize()
+	if page := route.paginator.Page; page < len(route.Routes) {
+		s.setRows(journeyKey(route.Routes[page]))
+	} else {
+		s.setRows("")
+	}
+
+	return s
+}
+
+// the table takes the top half of the map's cell, timelines the bottom
+func (s *compareState) resize() {
+	s.table.SetWidth(s.root.Main.GetWidth() - 4)
+	s.table.SetHeight((s.root.Main.GetHeight() - 4) / 2)
+}
+
+// timelinesView draws the shown journeys' timelines in table order against
+// the same clock, so waits and rides line up between them
+func (s *compareState) timelinesView() string {
+	journeys := make([]api.Journey, 0, len(s.shown))
+	for _, i := range s.shown {
+		journeys = append(journeys, s.route.Routes[i])
+	}
+	from, to := timelineAxis(journeys)
+	if from.IsZero() {
+		return ""
+	}
+
+	loc := s.route.loc
+	labelWidth := 10
+	width := s.root.Main.GetWidth() - 4 - labelWidth
+
+	// from and to along the top
+	axis := lipgloss.JoinHorizontal(lipgloss.Top,
+		strings.Repeat(" ", labelWidth),
+		lipgloss.NewStyle().Width(width/2).Render(from.In(loc).Format("3:04pm")),
+		lipgloss.NewStyle().Width(width-width/2).Align(lipgloss.Right).Render(to.In(loc).Format("3:04pm")),
+	)
+
+	rows := []string{axis}
+	height := s.root.Main.GetHeight() - 4 - s.table.Height() - 3
+	for row, j := range journeys {
+		if row >= height {
+			break
+		}
+		label := "  " + format.Time(loc, j.Legs[0].Origin.DepartureTimeEstimated)
+		if row == s.table.Cursor() {
+			label = "> " + format.Time(loc, j.Legs[0].Origin.DepartureTimeEstimated)
+		}
+		label = lipgloss.NewStyle().Width(labelWidth).Render(label)
+		rows = append(rows, label+journeyTimeline(j, from, to, width))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// selected is the index into route.Routes of the journey under the cursor
+func (s *compareState) selected() int {
+	if cursor := s.table.Cursor(); cursor >= 0 && cursor < len(s.shown) && s.shown[cursor] < len(s.route.Routes) {
+		return s.shown[cursor]
+	}
+	return -1
+}
+
+// selectedKey is the journeyKey of the journey under the cursor, as it was
+// when the rows were set
+func (s *compareState) selectedKey() string {
+	if cursor := s.table.Cursor(); cursor >= 0 && cursor < len(s.shownKeys) {
+		return s.shownKeys[cursor]
+	}
+	return ""
+}
+
+// setRows filters and sorts the route view's journeys into the table,
+// keeping the cursor on the selected journey if it's still shown
+func (s *compareState) setRows(selected string) {
+	routes := s.route.Routes
+	excluded := stopModeFilters[s.excludeMode]
+	maxChanges := maxChangesFilters[s.maxChanges]
+
+	s.shown = s.shown[:0]
+	for i, j := range routes {
+		if len(j.Legs) == 0 {
+			continue
+		}
+		if excluded != api.ModeAny && j.Modes().Has(excluded) {
+			continue
+		}
+		if maxChanges >= 0 && j.Changes() > maxChanges {
+			continue
+		}
+		s.shown = append(s.shown, i)
+	}
+
+	// stable, so ties keep the planner's order
+	less := journeySorts[s.sort].less
+	slices.SortStableFunc(s.shown, func(a, b int) int {
+		return less(routes[a], routes[b])
+	})
+
+	rows := make([]table.Row, 0, len(s.shown))
+	s.shownKeys = s.shownKeys[:0]
+	cursor := 0
+	for row, i := range s.shown {
+		rows = append(rows, s.journeyRow(routes[i]))
+		key := journeyKey(routes[i])
+		s.shownKeys = append(s.shownKeys, key)
+		if key == selected {
+			cursor = row
+		}
+	}
+	s.table.SetRows(rows)
+	s.table.SetCursor(cursor)
+}
+
+func (s *compareState) journeyRow(j api.Journey) table.Row {
+	loc := s.route.loc
+	first, last := j.Legs[0], j.Legs[len(j.Legs)-1]
+
+	duration := "n/a"
+	if minutes, ok := format.JourneyMinutes(j); ok {
+		duration = fmt.Sprintf("%dmin", minutes)
+	}
+
+	fare := "n/a"
+	if price, ok := j.AdultFare(); ok {
+		fare = fmt.Sprintf("$%.2f", price)
+	}
+
+	return table.Row{
+		format.Time(loc, first.Origin.DepartureTimeEstimated),
+		format.Time(loc, last.Destination.ArrivalTimeEstimated),
+		duration,
+		strconv.Itoa(j.Changes()),
+		fmt.Sprintf("%dmin", int(j.WalkingTime().Minutes())),
+		fare,
+		styles.IconsForModes(j.Modes()),
+	}
+}
+
+// filtersText describes the sort and filters, and the keys that change them
+func (s *compareState) filtersText() string {
+	var doc strings.Builder
+
+	fmt.Fprintf(&doc, "%d of %d journeys\n\n", len(s.shown), len(s.route.Routes))
+	fmt.Fprintf(&doc, "s  sorted by %s\n", journeySorts[s.sort].name)
+
+	if excluded := stopModeFilters[s.excludeMode]; excluded == api.ModeAny {
+		doc.WriteString("x  all modes\n")
+	} else {
+		fmt.Fprintf(&doc, "x  no %s\n", excluded)
+	}
+
+	switch maxChanges := maxChangesFilters[s.maxChanges]; maxChanges {
+	case -1:
+		doc.WriteString("c  any number of changes\n")
+	case 1:
+		doc.WriteString("c  at most 1 change\n")
+	default:
+		fmt.Fprintf(&doc, "c  at most %d changes\n", maxChanges)
+	}
+
+	doc.WriteString("\nenter to see a journey, esc to go back")
+	return doc.String()
+}
+
+func (s *compareState) Update(msg tea.Msg) (AppState, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.resize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, compareKeymapDefault.Sort):
+			s.sort = (s.sort + 1) % len(journeySorts)
+		case key.Matches(msg, compareKeymapDefault.ExcludeMode):
+			s.excludeMode = cycleModeFilter(s.excludeMode, 1)
+		case key.Matches(msg, compareKeymapDefault.MaxChanges):
+			s.maxChanges = (s.maxChanges + 1) % len(maxChangesFilters)
+		case key.Matches(msg, compareKeymapDefault.Select):
+			// the journeys may have been refreshed since the rows were set
+			s.setRows(s.selectedKey())
+			if selected := s.selected(); selected >= 0 {
+				s.route.showJourney(selected)
+				s.root.States.Pop()
+				return s, nil
+			}
+		default:
+			s.table, cmd = s.table.Update(msg)
+		}
+	}
+
+	s.RenderCells(s.root.flexBox)
+	return s, cmd
+}
+
+func (s *compareState) RenderCells(f *flexbox.FlexBox) {
+	// the route view refreshes underneath, so pick up any new journeys
+	s.setRows(s.selectedKey())
+
+	s.root.Main.SetContent(s.table.View() + "\n\n" + s.timelinesView())
+	s.root.Sidebar.SetContent(lipgloss.NewStyle().Width(s.route.legWidth).Render(s.filtersText()))
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/76creates/stickers/flexbox"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
+	"github.com/isobelmcrae/trip/styles"
+)
+
+// departuresState is a departure board for the root's origin
+type departuresState struct {
+	root       *RootModel
+	departures []api.StopEvent
+	viewport   viewport.Model
+	loc        *time.Location
+	width      int
+}
+
+// newDeparturesState fetches the services leaving the root's origin.
+func newDeparturesState(root *RootModel) AppState {
+	location, _ := time.LoadLocation("Australia/Sydney")
+
+	s := &departuresState{
+		root: root,
+		loc:  location,
+	}
+
+	// TODO: handle req which take a long time
+	departures, err := root.Client.Departures(context.TODO(), root.OriginID, root.TripOptions.At)
+	if err != nil {
+		log.Debug("Error when fetching departures", "err", err)
+	}
+	s.departures = departures
+
+	s.viewport = viewport.New(0, 0)
+	s.resize()
+
+	return s
+}
+
+// resize fits the board to the sidebar, same as the route view
+func (s *departuresState) resize() {
+	bigWidth := s.root.flexBox.GetWidth()
+	s.width = int(math.Floor(float64(bigWidth)/10)*3) - 6
+	s.viewport.Width = s.width
+	s.viewport.Height = s.root.flexBox.GetHeight() - 6
+	s.viewport.SetContent(s.formatDepartures())
+}
+
+// formatDepartures renders one line per service, soonest first
+func (s *departuresState) formatDepartures() string {
+	if len(s.departures) == 0 {
+		return lipgloss.NewStyle().Width(s.width).Align(lipgloss.Center).Render("No departures found.")
+	}
+
+	var doc strings.Builder
+	title := fmt.Sprintf("Departures from %s\n\n", s.root.OriginName)
+	doc.WriteString(lipgloss.NewStyle().Width(s.width).Bold(true).Render(title))
+
+	for _, d := range s.departures {
+		line := "?"
+		destination := ""
+		if d.Transportation != nil {
+			line = d.Transportation.DisassembledName
+			destination = d.Transportation.Destination.Name
+		}
+
+		departure := d.DepartureTimeEstimated
+		if departure == "" {
+			departure = d.DepartureTimePlanned
+		}
+
+		lineStr := styles.CreateLineHighlight(line).Render(fmt.Sprintf("[%s]", line))
+		row := fmt.Sprintf("%s %s %s | %s", format.Time(s.loc, departure), lineStr, destination, d.Location.DisassembledName)
+		doc.WriteString(lipgloss.NewStyle().Width(s.width).Render(row) + "\n")
+	}
+
+	return doc.String()
+}
+
+func (s *departuresState) RenderCells(f *flexbox.FlexBox) {
+	s.root.Sidebar.SetContent(s.viewport.View())
+}
+
+func (s *departuresState) Update(msg tea.Msg) (AppState, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.(type) {
+	case tea.WindowSizeMsg:
+		s.resize()
+	default:
+		s.viewport, cmd = s.viewport.Update(msg)
+	}
+
+	s.RenderCells(s.root.flexBox)
+	return s, cmd
+}
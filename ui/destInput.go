@@ -2,6 +2,7 @@ package ui
 
 import (
     "github.com/76creates/stickers/flexbox"
+    "github.com/charmbracelet/bubbles/key"
     "github.com/charmbracelet/bubbles/textinput"
     tea "github.com/charmbracelet/bubbletea"
     "github.com/isobelmcrae/trip/styles"
@@ -11,16 +12,31 @@ import (
 type destInputState struct {
     root *RootModel
     input textinput.Model
+    recall historyRecall
 }
 
 func (s *destInputState) Update(msg tea.Msg) (AppState, tea.Cmd){
+    if msg, ok := msg.(tea.KeyMsg); ok {
+        switch {
+        case key.Matches(msg, historyKeymapDefault.Older):
+            s.recall.Reload(historyEntries(s.root.History, false))
+            s.recall.Older(&s.input)
+            return s, nil
+        case key.Matches(msg, historyKeymapDefault.Newer):
+            s.recall.Newer(&s.input)
+            return s, nil
+        }
+    }
+
     var cmd tea.Cmd
     s.input, cmd = s.input.Update(msg)
+    s.recall.Sync(s.input)
 
     switch msg := msg.(type) {
     case tea.KeyMsg:
         if msg.Type == tea.KeyEnter {
             log.Debug("User input", "input", s.input.Value())
+            s.root.recordSearch(s.input.Value())
             s.root.States.Push(newDestSelectState(s.root, s.input.Value()))
             return s, cmd
         }
@@ -48,6 +64,7 @@ func newDestInputState(root *RootModel) AppState {
 
     return &destInputState{
         input: ti,
+        recall: newHistoryRecall(historyEntries(root.History, false)),
         root: root,
     }
 }
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"github.com/76creates/stickers/flexbox"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
         "github.com/charmbracelet/log"
@@ -15,11 +16,15 @@ type destSelectState struct {
     selectionList list.Model
     listSize int
     input string
+    modeFilter int // index into stopModeFilters
+    station string // the station whose platforms are listed, if any
+    stationName string
 }
 
 type destStopItem struct {
     title string
     id string
+    description string
 }
 
 func (sI destStopItem) Title() string {
@@ -27,7 +32,7 @@ func (sI destStopItem) Title() string {
 }
 
 func (sI destStopItem) Description() string {
-    return sI.id
+    return sI.description
 }
 
 func (sI destStopItem) FilterValue() string {
@@ -41,6 +46,38 @@ func (s *destSelectState) Update(msg tea.Msg) (AppState, tea.Cmd){
 
     switch msg := msg.(type) {
     case tea.KeyMsg:
+        // the list uses tab itself while filtering
+        if s.selectionList.FilterState() != list.Filtering {
+            switch {
+            case key.Matches(msg, stopFilterKeymapDefault.Platforms) && s.station != "":
+                // back to the search results
+                s.station, s.stationName = "", ""
+                destSelectStop(s)
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.Platforms) && s.listSize > 0:
+                selected := s.selectionList.SelectedItem().(destStopItem)
+                if len(s.root.Client.FindPlatforms(selected.id)) > 0 {
+                    s.station, s.stationName = selected.id, selected.title
+                    destSelectStop(s)
+                }
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.NextMode):
+                s.station, s.stationName = "", ""
+                s.modeFilter = cycleModeFilter(s.modeFilter, 1)
+                destSelectStop(s)
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.PrevMode):
+                s.station, s.stationName = "", ""
+                s.modeFilter = cycleModeFilter(s.modeFilter, -1)
+                destSelectStop(s)
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.Save) && s.listSize > 0:
+                selected := s.selectionList.SelectedItem().(destStopItem)
+                s.root.States.Push(newSaveStopState(s.root, selected.id, selected.title))
+                return s, cmd
+            }
+        }
+
         if msg.Type == tea.KeyEnter {
             // create and push next state IF there are stops
             if s.listSize == 0 {
@@ -50,9 +87,10 @@ func (s *destSelectState) Update(msg tea.Msg) (AppState, tea.Cmd){
 
             selectedItem := s.selectionList.SelectedItem()
 
-            selectedID := selectedItem.(destStopItem).id
-            log.Debug("stop selected", "id", selectedID)
-            s.root.DestinationID = selectedID
+            selected := selectedItem.(destStopItem)
+            log.Debug("stop selected", "id", selected.id)
+            s.root.DestinationID = selected.id
+            s.root.DestinationName = selected.title
 
             s.root.States.Push(newRouteState(s.root))
 
@@ -66,7 +104,7 @@ func (s *destSelectState) Update(msg tea.Msg) (AppState, tea.Cmd){
 
 // updates sidebar flexbox to display the selection list
 func (s *destSelectState) RenderCells(f *flexbox.FlexBox) {
-    prompt := "Select stop:\n"
+    prompt := stopSelectPrompt(s.modeFilter, s.stationName)
 
     // TODO: better way to store these values?
     sidebarHeight := s.root.Sidebar.GetHeight()
@@ -102,7 +140,7 @@ func newDestSelectState(root *RootModel, input string) AppState {
 
 // gets the stops which match the input string, formats them into a selection list
 func destSelectStop(m *destSelectState) {
-    stops := m.root.Client.FindStop(m.input)
+    stops := stopsFor(m.root, m.input, m.modeFilter, m.station)
 
     m.listSize = len(stops)
     if m.listSize == 0 {
@@ -111,7 +149,7 @@ func destSelectStop(m *destSelectState) {
 
     listItems := make([]list.Item, len(stops))
     for i, stop := range stops {
-        listItems[i] = destStopItem{ title: stop.Name, id: stop.ID }
+        listItems[i] = destStopItem{ title: stop.Name, id: stop.ID, description: stopDescription(stop) }
     }
 
     m.selectionList.SetItems(listItems)
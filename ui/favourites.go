@@ -0,0 +1,182 @@
+package ui
+
+import (
+    "fmt"
+
+    "github.com/76creates/stickers/flexbox"
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/list"
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/log"
+    "github.com/isobelmcrae/trip/state"
+    "github.com/isobelmcrae/trip/styles"
+)
+
+type favouritesKeymap struct {
+    Remove key.Binding
+    Reverse key.Binding
+}
+
+var favouritesKeymapDefault = favouritesKeymap{
+    Remove: key.NewBinding(key.WithKeys("x")),
+    Reverse: key.NewBinding(key.WithKeys("r")),
+}
+
+// favouritesState is the first screen when the user has saved anything,
+// it jumps straight to a saved trip or starts from a favourite stop
+type favouritesState struct {
+    root *RootModel
+    selectionList list.Model
+    // the favourites' version the list was loaded from
+    version int
+}
+
+type favouriteItem struct {
+    title string
+    description string
+    trip *state.SavedTrip
+    stop *state.FavouriteStop
+}
+
+func (fI favouriteItem) Title() string {
+    return fI.title
+}
+
+func (fI favouriteItem) Description() string {
+    return fI.description
+}
+
+func (fI favouriteItem) FilterValue() string {
+    return fI.title
+}
+
+func (s *favouritesState) Update(msg tea.Msg) (AppState, tea.Cmd){
+    var cmd tea.Cmd
+
+    s.selectionList, cmd = s.selectionList.Update(msg)
+
+    switch msg := msg.(type) {
+    case tea.KeyMsg:
+        if s.selectionList.FilterState() == list.Filtering {
+            return s, cmd
+        }
+
+        selected, ok := s.selectionList.SelectedItem().(favouriteItem)
+        if !ok {
+            return s, cmd
+        }
+
+        switch {
+        case msg.Type == tea.KeyEnter:
+            switch {
+            case selected.trip != nil:
+                log.Debug("saved trip selected", "name", selected.trip.Name)
+                s.root.OriginID, s.root.OriginName = selected.trip.OriginID, selected.trip.OriginName
+                s.root.DestinationID, s.root.DestinationName = selected.trip.DestinationID, selected.trip.DestinationName
+                s.root.States.Push(newRouteState(s.root))
+            case selected.stop != nil:
+                log.Debug("favourite stop selected", "name", selected.stop.Name)
+                s.root.OriginID, s.root.OriginName = selected.stop.StopID, selected.stop.StopName
+                s.root.States.Push(s.root.afterOrigin())
+            default:
+                s.root.States.Push(newOriginInputState(s.root))
+            }
+            return s, cmd
+
+        // plan a saved trip the other way round
+        case key.Matches(msg, favouritesKeymapDefault.Reverse) && selected.trip != nil:
+            log.Debug("saved trip reversed", "name", selected.trip.Name)
+            s.root.OriginID, s.root.OriginName = selected.trip.DestinationID, selected.trip.DestinationName
+            s.root.DestinationID, s.root.DestinationName = selected.trip.OriginID, selected.trip.OriginName
+            s.root.States.Push(newRouteState(s.root))
+            return s, cmd
+
+        case key.Matches(msg, favouritesKeymapDefault.Remove):
+            var err error
+            switch {
+            case selected.trip != nil:
+                err = s.root.Favourites.RemoveTrip(selected.trip.Name)
+            case selected.stop != nil:
+                err = s.root.Favourites.RemoveStop(selected.stop.Name)
+            }
+            if err != nil {
+                log.Error("could not remove favourite", "err", err)
+            }
+            favouritesLoad(s)
+        }
+    }
+
+    return s, cmd
+}
+
+// updates sidebar flexbox to display the favourites
+func (s *favouritesState) RenderCells(f *flexbox.FlexBox) {
+    // the list may have changed while we were further up the stack
+    if s.version != s.root.Favourites.Version() {
+        favouritesLoad(s)
+    }
+
+    prompt := "Where to?\n"
+
+    sidebarHeight := s.root.Sidebar.GetHeight()
+    sidebarWidth := s.root.Sidebar.GetWidth()
+
+    s.selectionList.SetSize(sidebarWidth - 7, sidebarHeight - 10)
+
+    sidebar := styles.WelcomeSidebarContent.Render(styles.Prompt.Render(prompt) + s.selectionList.View())
+
+    f.GetRow(0).GetCell(1).
+        SetContent(sidebar).
+        SetStyle(styles.WelcomeSidebar)
+}
+
+// creates a new favourites state which can then
+// be pushed onto states
+func newFavouritesState(root *RootModel) AppState {
+    sl := list.New([]list.Item{}, list.NewDefaultDelegate(), 20, 10)
+    sl.SetShowTitle(false)
+    sl.SetShowHelp(false)
+    sl.SetShowStatusBar(false)
+
+    m := &favouritesState{
+        selectionList: sl,
+        root: root,
+    }
+
+    favouritesLoad(m)
+
+    return m
+}
+
+// saved trips first, then stops, then a way out to a fresh search
+func favouritesLoad(m *favouritesState) {
+    m.version = m.root.Favourites.Version()
+    trips, stops := m.root.Favourites.List()
+
+    listItems := make([]list.Item, 0, len(trips) + len(stops) + 1)
+    for i := range trips {
+        trip := trips[i]
+        listItems = append(listItems, favouriteItem{
+            title: trip.Name,
+            description: fmt.Sprintf("%s → %s", trip.OriginName, trip.DestinationName),
+            trip: &trip,
+        })
+    }
+    for i := range stops {
+        stop := stops[i]
+        listItems = append(listItems, favouriteItem{
+            title: stop.Name,
+            description: "from " + stop.StopName,
+            stop: &stop,
+        })
+    }
+    listItems = append(listItems, favouriteItem{
+        title: "New trip",
+        description: "search for a stop",
+    })
+
+    m.selectionList.SetItems(listItems)
+    if m.selectionList.Index() >= len(listItems) {
+        m.selectionList.Select(len(listItems) - 1)
+    }
+}
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// formatCountdown is how long until rawTime, e.g. "in 4 min", and empty
+// once it has passed
+func formatCountdown(now time.Time, rawTime string) string {
+	parsed, err := time.Parse(time.RFC3339, rawTime)
+	if err != nil {
+		return ""
+	}
+
+	until := parsed.Sub(now)
+	switch {
+	case until < 0:
+		return ""
+	case until < time.Minute:
+		return "now"
+	case until < time.Hour:
+		return fmt.Sprintf("in %d min", int(until.Minutes()))
+	}
+	return fmt.Sprintf("in %dh %02dmin", int(until.Hours()), int(until.Minutes())%60)
+}
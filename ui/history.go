@@ -0,0 +1,164 @@
+package ui
+
+import (
+    "fmt"
+    "slices"
+    "strings"
+    "time"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/textinput"
+    "github.com/charmbracelet/lipgloss"
+    "github.com/charmbracelet/log"
+    "github.com/isobelmcrae/trip/state"
+)
+
+type historyKeymap struct {
+    Older key.Binding
+    Newer key.Binding
+}
+
+// like shell history, up for older entries and down for newer
+var historyKeymapDefault = historyKeymap{
+    Older: key.NewBinding(key.WithKeys("up", "ctrl+p")),
+    Newer: key.NewBinding(key.WithKeys("down", "ctrl+n")),
+}
+
+type recallEntry struct {
+    query string // placed into the input when recalled
+    trip *state.HistoryTrip // set when recalling jumps straight to a trip
+    at time.Time
+}
+
+// historyRecall steps through past searches in a text input
+type historyRecall struct {
+    entries []recallEntry
+    cursor int // -1 while the user is typing their own input
+    draft string // what the user had typed before recalling
+}
+
+func newHistoryRecall(entries []recallEntry) historyRecall {
+    return historyRecall{entries: entries, cursor: -1}
+}
+
+// recent trips and searches merged, newest first
+func historyEntries(h *state.History, withTrips bool) []recallEntry {
+    trips, searches := h.List()
+
+    var entries []recallEntry
+    for _, search := range searches {
+        entries = append(entries, recallEntry{query: search.Query, at: search.At})
+    }
+    if withTrips {
+        for _, trip := range trips {
+            entries = append(entries, recallEntry{
+                query: fmt.Sprintf("%s → %s", trip.OriginName, trip.DestinationName),
+                trip: &trip,
+                at: trip.At,
+            })
+        }
+    }
+
+    slices.SortStableFunc(entries, func(a, b recallEntry) int {
+        return b.at.Compare(a.at)
+    })
+    return entries
+}
+
+// Reload replaces the entries unless the user is stepping through them
+func (h *historyRecall) Reload(entries []recallEntry) {
+    if h.cursor == -1 {
+        h.entries = entries
+    }
+}
+
+// Older recalls the previous entry into input
+func (h *historyRecall) Older(input *textinput.Model) {
+    if h.cursor + 1 >= len(h.entries) {
+        return
+    }
+    if h.cursor == -1 {
+        h.draft = input.Value()
+    }
+    h.cursor++
+    input.SetValue(h.entries[h.cursor].query)
+    input.CursorEnd()
+}
+
+// Newer recalls the next entry, or the user's own input past the newest
+func (h *historyRecall) Newer(input *textinput.Model) {
+    if h.cursor == -1 {
+        return
+    }
+    h.cursor--
+    if h.cursor == -1 {
+        input.SetValue(h.draft)
+    } else {
+        input.SetValue(h.entries[h.cursor].query)
+    }
+    input.CursorEnd()
+}
+
+// Sync forgets the recalled entry once the user edits it
+func (h *historyRecall) Sync(input textinput.Model) {
+    if h.cursor >= 0 && input.Value() != h.entries[h.cursor].query {
+        h.cursor = -1
+    }
+}
+
+// Selected is the recalled entry, if any
+func (h *historyRecall) Selected() *recallEntry {
+    if h.cursor < 0 {
+        return nil
+    }
+    return &h.entries[h.cursor]
+}
+
+// View lists up to max entries, marking the recalled one
+func (h *historyRecall) View(width int, max int) string {
+    if len(h.entries) == 0 || max <= 0 {
+        return ""
+    }
+
+    var doc strings.Builder
+    doc.WriteString(lipgloss.NewStyle().Bold(true).Render("Recent") + "\n")
+
+    for i, entry := range h.entries {
+        if i >= max {
+            break
+        }
+        marker := "  "
+        if i == h.cursor {
+            marker = "> "
+        }
+        age := formatAgo(entry.at)
+        line := lipgloss.NewStyle().MaxWidth(width - len(age) - 1).Render(marker + entry.query)
+        doc.WriteString(lipgloss.NewStyle().Width(width - len(age)).Render(line) + age + "\n")
+    }
+
+    return doc.String()
+}
+
+// formatAgo renders a timestamp relative to now, e.g. "5m ago"
+func formatAgo(t time.Time) string {
+    since := time.Since(t)
+    switch {
+    case since < time.Minute:
+        return "just now"
+    case since < time.Hour:
+        return fmt.Sprintf("%dm ago", int(since.Minutes()))
+    case since < 24*time.Hour:
+        return fmt.Sprintf("%dh ago", int(since.Hours()))
+    }
+    return t.Format("2 Jan")
+}
+
+// remembers a stop search for later recall
+func (m *RootModel) recordSearch(query string) {
+    if query == "" {
+        return
+    }
+    if err := m.History.AddSearch(state.HistorySearch{Query: query, At: time.Now()}); err != nil {
+        log.Error("could not save search history", "err", err)
+    }
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+    "github.com/charmbracelet/log"
+    "github.com/isobelmcrae/trip/api"
+)
+
+// LaunchOptions skip the first few screens, e.g. `trip --from home --to work`
+type LaunchOptions struct {
+    From string
+    To string
+    Departures string // show the departure board for this stop instead
+    Trip api.TripOptions
+}
+
+// Launch jumps to the journeys or departure board described by opts,
+// only stopping to ask when a stop name is ambiguous. It waits for the
+// terminal's size, which the journeys' layout and maps need
+func (m *RootModel) Launch(opts LaunchOptions) {
+    m.TripOptions = opts.Trip
+    m.pendingLaunch = &opts
+}
+
+func (m *RootModel) launch(opts LaunchOptions) {
+
+    if opts.Departures != "" {
+        m.pendingDepartures = true
+        m.pushOrigin(opts.Departures)
+        return
+    }
+
+    m.pendingTo = opts.To
+    if opts.From != "" {
+        m.pushOrigin(opts.From)
+    }
+}
+
+// pushOrigin moves on if query names exactly one stop, else lets the user pick
+func (m *RootModel) pushOrigin(query string) {
+    if id, name, ok := m.resolveStop(query); ok {
+        m.OriginID, m.OriginName = id, name
+        m.States.Push(m.afterOrigin())
+        return
+    }
+    m.States.Push(newOriginSelectState(m, query))
+}
+
+// afterOrigin is the state following the choice of origin, normally asking
+// for a destination unless one was given on the command line
+func (m *RootModel) afterOrigin() AppState {
+    if m.pendingDepartures {
+        m.pendingDepartures = false
+        return newDeparturesState(m)
+    }
+
+    if to := m.pendingTo; to != "" {
+        m.pendingTo = ""
+        if id, name, ok := m.resolveStop(to); ok {
+            m.DestinationID, m.DestinationName = id, name
+            return newRouteState(m)
+        }
+        return newDestSelectState(m, to)
+    }
+
+    return newDestInputState(m)
+}
+
+// resolveStop turns a favourite's name or a search into a single stop
+func (m *RootModel) resolveStop(query string) (id string, name string, ok bool) {
+    if fav, ok := m.Favourites.FindStop(query); ok {
+        return fav.StopID, fav.StopName, true
+    }
+
+    stops := m.Client.ResolveStop(query)
+    if len(stops) == 1 {
+        return stops[0].ID, stops[0].Name, true
+    }
+
+    log.Debug("ambiguous stop", "query", query, "results", len(stops))
+    return "", "", false
+}
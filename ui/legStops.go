@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/76creates/stickers/flexbox"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
+	"github.com/isobelmcrae/trip/styles"
+)
+
+// legStopsState lists every stop a leg calls at, with the cursor's stop
+// marked on the map
+type legStopsState struct {
+	root     *RootModel
+	legs     []api.Leg
+	legIdx   int
+	cursor   int
+	viewport viewport.Model
+	loc      *time.Location
+	width    int
+	mapCtl   mapControl
+	jmap     *journeyMap // the route view's, for the journey the leg is in
+}
+
+func newLegStopsState(root *RootModel, legs []api.Leg, legIdx int, loc *time.Location, jmap *journeyMap) AppState {
+	s := &legStopsState{
+		root:   root,
+		legs:   legs,
+		legIdx: legIdx,
+		loc:    loc,
+		jmap:   jmap,
+	}
+
+	s.viewport = viewport.New(0, 0)
+	s.resize()
+
+	return s
+}
+
+func (s *legStopsState) canFullScreen() {}
+
+func (s *legStopsState) leg() api.Leg {
+	return s.legs[s.legIdx]
+}
+
+// resize fits the list to the sidebar, same as the route view
+func (s *legStopsState) resize() {
+	bigWidth := s.root.flexBox.GetWidth()
+	s.width = int(math.Floor(float64(bigWidth)/10)*3) - 6
+	s.viewport.Width = s.width
+	s.viewport.Height = s.root.flexBox.GetHeight() - 6
+	s.setContent()
+}
+
+// header is the line and how far it goes, two lines tall
+func (s *legStopsState) header() string {
+	l := s.leg()
+	transport := format.LegTransport(l)
+	line := styles.CreateLineHighlight(transport).Render(fmt.Sprintf("[%s]", transport))
+
+	return fmt.Sprintf("%s %s → %s\n%d stops\n\n", line, l.Origin.DisassembledName, l.Destination.DisassembledName, len(l.StopSequence)-1)
+}
+
+// setContent lists the stops, the cursor's one picked out along with how
+// many stops are left after it
+func (s *legStopsState) setContent() {
+	header := s.header()
+	stops := s.leg().StopSequence
+
+	var doc strings.Builder
+	doc.WriteString(header)
+	for i, stop := range stops {
+		row := fmt.Sprintf("%s  %s", s.stopTimeText(stop), stop.DisassembledName)
+		if stop.DisassembledName == "" {
+			row = fmt.Sprintf("%s  %s", s.stopTimeText(stop), stop.Name)
+		}
+		if i == s.cursor {
+			row = lipgloss.NewStyle().Bold(true).Render("> "+row) + fmt.Sprintf(" (%s)", stopsToGo(len(stops)-1-i))
+		} else {
+			row = "  " + row
+		}
+		doc.WriteString(lipgloss.NewStyle().Width(s.width).Render(row) + "\n")
+	}
+
+	s.viewport.SetContent(doc.String())
+
+	// keep the cursor in view, counting the header's lines
+	line := strings.Count(header, "\n") + s.cursor
+	if line < s.viewport.YOffset {
+		s.viewport.SetYOffset(line)
+	} else if line >= s.viewport.YOffset+s.viewport.Height {
+		s.viewport.SetYOffset(line - s.viewport.Height + 1)
+	}
+}
+
+// stopTimeText is when the service calls at the stop, with the estimate
+// coloured like a delay badge when it differs from the timetable
+func (s *legStopsState) stopTimeText(stop api.JourneyStop) string {
+	planned, estimated := stop.DepartureTimePlanned, stop.DepartureTimeEstimated
+	if planned == "" {
+		planned, estimated = stop.ArrivalTimePlanned, stop.ArrivalTimeEstimated
+	}
+
+	text := format.Time(s.loc, planned)
+	if estimated == "" || estimated == planned {
+		return text
+	}
+
+	p, err := time.Parse(time.RFC3339, planned)
+	if err != nil {
+		return text
+	}
+	e, err := time.Parse(time.RFC3339, estimated)
+	if err != nil {
+		return text
+	}
+	minutes := int(e.Sub(p).Round(time.Minute).Minutes())
+	return fmt.Sprintf("%s %s", text, styles.DelayStyle(minutes).Render("→ "+format.Time(s.loc, estimated)))
+}
+
+func stopsToGo(n int) string {
+	switch n {
+	case 0:
+		return "last stop"
+	case 1:
+		return "1 stop to go"
+	}
+	return fmt.Sprintf("%d stops to go", n)
+}
+
+func (s *legStopsState) Update(msg tea.Msg) (AppState, tea.Cmd) {
+	fit := func() mapView {
+		return fitLegMap(s.root, s.legs, s.jmap.paths, s.legIdx)
+	}
+	if s.mapCtl.Update(msg, s.root, fit) {
+		s.RenderCells(s.root.flexBox)
+		return s, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.resize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, legSelectionKeymapDefault.NextLeg):
+			if s.cursor < len(s.leg().StopSequence)-1 {
+				s.cursor++
+				s.setContent()
+			}
+		case key.Matches(msg, legSelectionKeymapDefault.PrevLeg):
+			if s.cursor > 0 {
+				s.cursor--
+				s.setContent()
+			}
+		}
+	}
+
+	s.RenderCells(s.root.flexBox)
+	return s, nil
+}
+
+func (s *legStopsState) RenderCells(f *flexbox.FlexBox) {
+	s.root.Sidebar.SetContent(s.viewport.View())
+
+	stops := s.leg().StopSequence
+	var stop *api.JourneyStop
+	if s.cursor < len(stops) {
+		stop = &stops[s.cursor]
+	}
+	// the header's first line, the line and where it goes
+	overlay, _, _ := strings.Cut(s.header(), "\n")
+	if stop != nil {
+		overlay = fmt.Sprintf("%s  at %s", overlay, stop.DisassembledName)
+	}
+	renderLegMap(s.root, s.legs, s.legIdx, legMapOptions{jmap: s.jmap, stop: stop, view: s.mapCtl.view, overlay: overlay})
+}
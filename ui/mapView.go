@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/isobelmcrae/trip/rendermaps"
+)
+
+// mapView is where the map is looking
+type mapView struct {
+	lat, lon, zoom float64
+}
+
+type mapKeymap struct {
+	PanUp      key.Binding
+	PanDown    key.Binding
+	PanLeft    key.Binding
+	PanRight   key.Binding
+	ZoomIn     key.Binding
+	ZoomOut    key.Binding
+	Recentre   key.Binding
+	FullScreen key.Binding
+}
+
+// shift+arrows or HJKL to pan, since the plain keys already move between
+// legs and journeys
+var mapKeymapDefault = mapKeymap{
+	PanUp:      key.NewBinding(key.WithKeys("shift+up", "K")),
+	PanDown:    key.NewBinding(key.WithKeys("shift+down", "J")),
+	PanLeft:    key.NewBinding(key.WithKeys("shift+left", "H")),
+	PanRight:   key.NewBinding(key.WithKeys("shift+right", "L")),
+	ZoomIn:     key.NewBinding(key.WithKeys("+", "=")),
+	ZoomOut:    key.NewBinding(key.WithKeys("-", "_")),
+	Recentre:   key.NewBinding(key.WithKeys("0")),
+	FullScreen: key.NewBinding(key.WithKeys("m")),
+}
+
+// how far a zoom key or wheel notch zooms
+const mapZoomStep = 1.0
+
+// mapControl lets the user move the map about. Until they do, view is nil
+// and the map follows whatever is focused
+type mapControl struct {
+	view     *mapView
+	dragging bool
+	dragX    int
+	dragY    int
+}
+
+// Update applies msg to the map if it's a map key, or the mouse over the
+// map's cell, reporting whether it was. fit is where the map would look
+// if left alone
+func (c *mapControl) Update(msg tea.Msg, root *RootModel, fit func() mapView) bool {
+	width, height := root.Main.GetWidth(), root.Main.GetHeight()
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, mapKeymapDefault.PanUp):
+			c.pan(fit, 0, -height/4)
+		case key.Matches(msg, mapKeymapDefault.PanDown):
+			c.pan(fit, 0, height/4)
+		case key.Matches(msg, mapKeymapDefault.PanLeft):
+			c.pan(fit, -width/4, 0)
+		case key.Matches(msg, mapKeymapDefault.PanRight):
+			c.pan(fit, width/4, 0)
+		case key.Matches(msg, mapKeymapDefault.ZoomIn):
+			c.zoom(fit, mapZoomStep)
+		case key.Matches(msg, mapKeymapDefault.ZoomOut):
+			c.zoom(fit, -mapZoomStep)
+		case key.Matches(msg, mapKeymapDefault.Recentre):
+			c.view = nil
+		case key.Matches(msg, mapKeymapDefault.FullScreen):
+			root.SetFullScreen(!root.FullScreen)
+		default:
+			return false
+		}
+		return true
+
+	case tea.MouseMsg:
+		// the map is the first cell, so anything left of its edge is on it
+		if msg.X >= width && !c.dragging {
+			return false
+		}
+
+		switch {
+		case msg.Button == tea.MouseButtonWheelUp:
+			c.zoom(fit, mapZoomStep)
+		case msg.Button == tea.MouseButtonWheelDown:
+			c.zoom(fit, -mapZoomStep)
+		case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+			c.dragging, c.dragX, c.dragY = true, msg.X, msg.Y
+		case msg.Action == tea.MouseActionMotion && c.dragging:
+			// the map follows the pointer, so it pans the opposite way
+			c.pan(fit, c.dragX-msg.X, c.dragY-msg.Y)
+			c.dragX, c.dragY = msg.X, msg.Y
+		case msg.Action == tea.MouseActionRelease:
+			c.dragging = false
+		default:
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// current is where the map is looking now
+func (c *mapControl) current(fit func() mapView) mapView {
+	if c.view != nil {
+		return *c.view
+	}
+	return fit()
+}
+
+func (c *mapControl) pan(fit func() mapView, dx int, dy int) {
+	v := c.current(fit)
+	v.lat, v.lon = rendermaps.Pan(v.lat, v.lon, v.zoom, dx, dy)
+	c.view = &v
+}
+
+func (c *mapControl) zoom(fit func() mapView, step float64) {
+	v := c.current(fit)
+	v.zoom = max(rendermaps.MinZoom, min(rendermaps.MaxZoom, v.zoom+step))
+	c.view = &v
+}
+
+// Reset goes back to following the focus
+func (c *mapControl) Reset() {
+	c.view = nil
+	c.dragging = false
+}
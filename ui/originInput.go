@@ -2,6 +2,7 @@ package ui
 
 import (
     "github.com/76creates/stickers/flexbox"
+    "github.com/charmbracelet/bubbles/key"
     "github.com/charmbracelet/bubbles/textinput"
     tea "github.com/charmbracelet/bubbletea"
     "github.com/isobelmcrae/trip/styles"
@@ -11,17 +12,41 @@ import (
 type originInputState struct {
     root *RootModel
     input textinput.Model
+    recall historyRecall
     output *string
 }
 
 func (s *originInputState) Update(msg tea.Msg) (AppState, tea.Cmd){
+    if msg, ok := msg.(tea.KeyMsg); ok {
+        switch {
+        case key.Matches(msg, historyKeymapDefault.Older):
+            s.recall.Reload(historyEntries(s.root.History, true))
+            s.recall.Older(&s.input)
+            return s, nil
+        case key.Matches(msg, historyKeymapDefault.Newer):
+            s.recall.Newer(&s.input)
+            return s, nil
+        }
+    }
+
     var cmd tea.Cmd
     s.input, cmd = s.input.Update(msg)
+    s.recall.Sync(s.input)
 
     switch msg := msg.(type) {
     case tea.KeyMsg:
         if msg.Type == tea.KeyEnter {
+            // a recalled trip skips the searches entirely
+            if entry := s.recall.Selected(); entry != nil && entry.trip != nil {
+                log.Debug("recent trip selected", "origin", entry.trip.OriginID, "destination", entry.trip.DestinationID)
+                s.root.OriginID, s.root.OriginName = entry.trip.OriginID, entry.trip.OriginName
+                s.root.DestinationID, s.root.DestinationName = entry.trip.DestinationID, entry.trip.DestinationName
+                s.root.States.Push(newRouteState(s.root))
+                return s, cmd
+            }
+
             log.Debug("User origin input", "input", s.input.Value())
+            s.root.recordSearch(s.input.Value())
             s.root.States.Push(newOriginSelectState(s.root, s.input.Value()))
             return s, cmd
         }
@@ -34,6 +59,13 @@ func (s *originInputState) Update(msg tea.Msg) (AppState, tea.Cmd){
 func (s *originInputState) RenderCells(f *flexbox.FlexBox) {
     sidebar := styles.WelcomeSidebarContent.Render(s.input.View())
 
+    // recent trips and searches until the user starts typing
+    if s.input.Value() == "" || s.recall.Selected() != nil {
+        s.recall.Reload(historyEntries(s.root.History, true))
+        recent := s.recall.View(s.root.Sidebar.GetWidth() - 7, s.root.Sidebar.GetHeight() - 10)
+        sidebar += "\n\n" + styles.WelcomeSidebarContent.Render(recent)
+    }
+
     f.GetRow(0).GetCell(1).
         SetContent(styles.Prompt.Render("Where are you?") + "\n\n" + sidebar).
         SetStyle(styles.WelcomeSidebar)
@@ -49,6 +81,7 @@ func newOriginInputState(root *RootModel) AppState {
 
     return &originInputState{
         input: ti,
+        recall: newHistoryRecall(historyEntries(root.History, true)),
         root: root,
     }
 }
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"github.com/76creates/stickers/flexbox"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
         "github.com/charmbracelet/log"
@@ -15,12 +16,16 @@ type originSelectState struct {
     selectionList list.Model
     listSize int
     input string
+    modeFilter int // index into stopModeFilters
+    station string // the station whose platforms are listed, if any
+    stationName string
     output *string
 }
 
 type originStopItem struct {
     title string
     id string
+    description string
 }
 
 func (sI originStopItem) Title() string {
@@ -28,7 +33,7 @@ func (sI originStopItem) Title() string {
 }
 
 func (sI originStopItem) Description() string {
-    return sI.id
+    return sI.description
 }
 
 func (sI originStopItem) FilterValue() string {
@@ -42,6 +47,38 @@ func (s *originSelectState) Update(msg tea.Msg) (AppState, tea.Cmd){
 
     switch msg := msg.(type) {
     case tea.KeyMsg:
+        // the list uses tab itself while filtering
+        if s.selectionList.FilterState() != list.Filtering {
+            switch {
+            case key.Matches(msg, stopFilterKeymapDefault.Platforms) && s.station != "":
+                // back to the search results
+                s.station, s.stationName = "", ""
+                originSelectStop(s)
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.Platforms) && s.listSize > 0:
+                selected := s.selectionList.SelectedItem().(originStopItem)
+                if len(s.root.Client.FindPlatforms(selected.id)) > 0 {
+                    s.station, s.stationName = selected.id, selected.title
+                    originSelectStop(s)
+                }
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.NextMode):
+                s.station, s.stationName = "", ""
+                s.modeFilter = cycleModeFilter(s.modeFilter, 1)
+                originSelectStop(s)
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.PrevMode):
+                s.station, s.stationName = "", ""
+                s.modeFilter = cycleModeFilter(s.modeFilter, -1)
+                originSelectStop(s)
+                return s, cmd
+            case key.Matches(msg, stopFilterKeymapDefault.Save) && s.listSize > 0:
+                selected := s.selectionList.SelectedItem().(originStopItem)
+                s.root.States.Push(newSaveStopState(s.root, selected.id, selected.title))
+                return s, cmd
+            }
+        }
+
         if msg.Type == tea.KeyEnter {
             // create and push next state IF there are stops
             if s.listSize == 0 {
@@ -51,11 +88,12 @@ func (s *originSelectState) Update(msg tea.Msg) (AppState, tea.Cmd){
 
             selectedItem := s.selectionList.SelectedItem()
 
-            selectedID := selectedItem.(originStopItem).id
-            log.Debug("stop selected", "id", selectedID)
-            s.root.OriginID = selectedID
+            selected := selectedItem.(originStopItem)
+            log.Debug("stop selected", "id", selected.id)
+            s.root.OriginID = selected.id
+            s.root.OriginName = selected.title
 
-            s.root.States.Push(newDestInputState(s.root))
+            s.root.States.Push(s.root.afterOrigin())
 
             return s, cmd
         }
@@ -67,7 +105,7 @@ func (s *originSelectState) Update(msg tea.Msg) (AppState, tea.Cmd){
 
 // updates sidebar flexbox to display the selection list
 func (s *originSelectState) RenderCells(f *flexbox.FlexBox) {
-    prompt := "Select stop:\n"
+    prompt := stopSelectPrompt(s.modeFilter, s.stationName)
 
     // TODO: better way to store these values?
     sidebarHeight := s.root.Sidebar.GetHeight()
@@ -103,7 +141,7 @@ func newOriginSelectState(root *RootModel, input string) AppState {
 
 // gets the stops which match the input string, formats them into a selection list
 func originSelectStop(m *originSelectState) {
-    stops := m.root.Client.FindStop(m.input)
+    stops := stopsFor(m.root, m.input, m.modeFilter, m.station)
 
     m.listSize = len(stops)
     if m.listSize == 0 {
@@ -112,7 +150,7 @@ func originSelectStop(m *originSelectState) {
 
     listItems := make([]list.Item, len(stops))
     for i, stop := range stops {
-        listItems[i] = originStopItem{ title: stop.Name, id: stop.ID }
+        listItems[i] = originStopItem{ title: stop.Name, id: stop.ID, description: stopDescription(stop) }
     }
 
     m.selectionList.SetItems(listItems)
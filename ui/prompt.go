@@ -0,0 +1,69 @@
+package ui
+
+import (
+    "github.com/76creates/stickers/flexbox"
+    "github.com/charmbracelet/bubbles/textinput"
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/log"
+    "github.com/isobelmcrae/trip/styles"
+)
+
+// promptState asks the user for a line of text, e.g. a name before saving
+// a favourite, then pops itself off the stack. If onSubmit fails the prompt
+// stays open with the error, so the user can fix their answer or go back
+type promptState struct {
+    root *RootModel
+    input textinput.Model
+    prompt string
+    onSubmit func(value string) error
+    err error
+}
+
+func (s *promptState) Update(msg tea.Msg) (AppState, tea.Cmd){
+    var cmd tea.Cmd
+    s.input, cmd = s.input.Update(msg)
+
+    switch msg := msg.(type) {
+    case tea.KeyMsg:
+        if msg.Type == tea.KeyEnter && s.input.Value() != "" {
+            if err := s.onSubmit(s.input.Value()); err != nil {
+                log.Error("prompt failed", "prompt", s.prompt, "err", err)
+                s.err = err
+                s.RenderCells(s.root.flexBox)
+                return s, cmd
+            }
+            s.root.States.Pop()
+            return s, cmd
+        }
+        s.err = nil
+    }
+
+    return s, cmd
+}
+
+func (s *promptState) RenderCells(f *flexbox.FlexBox) {
+    content := s.input.View()
+    if s.err != nil {
+        content += "\n\n" + styles.PromptError.Render(s.err.Error())
+    }
+    sidebar := styles.WelcomeSidebarContent.Render(content)
+
+    f.GetRow(0).GetCell(1).
+        SetContent(styles.Prompt.Render(s.prompt) + "\n\n" + sidebar).
+        SetStyle(styles.WelcomeSidebar)
+}
+
+// creates a new prompt state, prefilled with value
+func newPromptState(root *RootModel, prompt string, value string, onSubmit func(value string) error) AppState {
+    ti := textinput.New()
+    ti.SetValue(value)
+    ti.Focus()
+    ti.Width = 30
+
+    return &promptState{
+        root: root,
+        input: ti,
+        prompt: prompt,
+        onSubmit: onSubmit,
+    }
+}
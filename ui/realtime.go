@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/styles"
+)
+
+// delayBadge describes how late a service is running, e.g. "+3 min late"
+func delayBadge(delay time.Duration, ok bool) string {
+	if !ok {
+		return styles.ScheduledOnly.Render("scheduled only")
+	}
+
+	minutes := int(delay.Round(time.Minute).Minutes())
+	switch {
+	case minutes == 0:
+		return styles.OnTime.Render("on time")
+	case minutes < 0:
+		return styles.OnTime.Render(fmt.Sprintf("%d min early", -minutes))
+	}
+	return styles.DelayStyle(minutes).Render(fmt.Sprintf("+%d min late", minutes))
+}
+
+// legBadge is the realtime badge for a leg's departure, empty for walks
+func legBadge(l api.Leg) string {
+	if l.IsWalk() {
+		return ""
+	}
+	if l.IsCancelled() {
+		return styles.Cancelled.Render("cancelled")
+	}
+	return delayBadge(l.DepartureDelay())
+}
+
+// journeyBadges are the realtime badges for the journey header: how its
+// first service leaves and its last arrives, or that one is cancelled
+func journeyBadges(j api.Journey) (departure string, arrival string, cancelled bool) {
+	var first, last *api.Leg
+	for i := range j.Legs {
+		l := &j.Legs[i]
+		if l.IsWalk() {
+			continue
+		}
+		if l.IsCancelled() {
+			return styles.Cancelled.Render("cancelled"), "", true
+		}
+		if first == nil {
+			first = l
+		}
+		last = l
+	}
+
+	if first == nil {
+		return "", "", false
+	}
+	return delayBadge(first.DepartureDelay()), delayBadge(last.ArrivalDelay()), false
+}
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
+)
+
+const (
+	// how often an armed reminder checks whether it's time to go
+	reminderTickInterval = 30 * time.Second
+	// how often it asks the API whether the service is running late
+	reminderRefreshInterval = 2 * time.Minute
+)
+
+// reminder tells the user when to leave for a journey. It lives on the
+// root rather than routeState so it keeps going whatever is on screen
+type reminder struct {
+	id int
+
+	originID      string
+	destinationID string
+	journey       string // journeyKey of the journey it's for
+	service       string // e.g. "T4 from Central", for the notification
+	departure     time.Time
+	before        time.Duration
+	leaveAt       time.Time
+	refreshed     time.Time
+	scheduled     bool
+}
+
+// due is when the notification goes off
+func (r *reminder) due() time.Time {
+	return r.leaveAt.Add(-r.before)
+}
+
+type reminderTickMsg struct{ id int }
+
+type reminderRefreshMsg struct {
+	id      int
+	leaveAt time.Time
+	found   bool
+}
+
+// journeyKey tells the same journey apart across refreshes, by its first
+// service and when that was timetabled to leave
+func journeyKey(j api.Journey) string {
+	l, ok := j.FirstService()
+	if !ok {
+		if len(j.Legs) == 0 {
+			return ""
+		}
+		l = j.Legs[0]
+		return "walk|" + l.Origin.ID + "|" + l.Destination.ID
+	}
+	return l.Transportation.ID + "|" + l.Origin.ID + "|" + l.Origin.DepartureTimePlanned
+}
+
+// armReminder replaces any reminder with one for j, going off before
+// ahead of when the user has to leave
+func (m *RootModel) armReminder(j api.Journey, before time.Duration) error {
+	leaveAt, ok := j.LeaveAt()
+	if !ok {
+		return fmt.Errorf("journey has no departure time")
+	}
+
+	r := &reminder{
+		originID:      m.OriginID,
+		destinationID: m.DestinationID,
+		journey:       journeyKey(j),
+		before:        before,
+		leaveAt:       leaveAt,
+		refreshed:     time.Now(),
+	}
+	m.reminderSeq++
+	r.id = m.reminderSeq
+
+	service := j.Legs[0]
+	if l, ok := j.FirstService(); ok {
+		service = l
+	}
+	r.service = fmt.Sprintf("%s from %s", format.LegTransport(service), service.Origin.DisassembledName)
+	if planned, err := time.Parse(time.RFC3339, service.Origin.DepartureTimePlanned); err == nil {
+		r.departure = planned
+	}
+
+	m.reminder = r
+	return nil
+}
+
+// cancelReminder drops the reminder, its pending ticks are ignored
+func (m *RootModel) cancelReminder() {
+	m.reminder = nil
+}
+
+// scheduleReminder starts a newly armed reminder ticking
+func (m *RootModel) scheduleReminder() tea.Cmd {
+	r := m.reminder
+	if r == nil || r.scheduled {
+		return nil
+	}
+	r.scheduled = true
+	return reminderTick(r)
+}
+
+// reminderTick waits for the next check, sooner if the reminder is due
+// before then
+func reminderTick(r *reminder) tea.Cmd {
+	wait := min(reminderTickInterval, time.Until(r.due()))
+	id := r.id
+	return tea.Tick(max(wait, 0), func(time.Time) tea.Msg {
+		return reminderTickMsg{id: id}
+	})
+}
+
+// updateReminder handles the reminder's own messages, ignoring any left
+// over from a reminder that has since been replaced or cancelled
+func (m *RootModel) updateReminder(msg tea.Msg) tea.Cmd {
+	r := m.reminder
+
+	switch msg := msg.(type) {
+	case reminderTickMsg:
+		if r == nil || msg.id != r.id {
+			return nil
+		}
+		if !time.Now().Before(r.due()) {
+			m.reminder = nil
+			return m.notify("trip", fmt.Sprintf("Leave now for the %s", r.service))
+		}
+		cmds := []tea.Cmd{reminderTick(r)}
+		if time.Since(r.refreshed) >= reminderRefreshInterval {
+			r.refreshed = time.Now()
+			cmds = append(cmds, m.refreshReminder(r))
+		}
+		return tea.Batch(cmds...)
+
+	case reminderRefreshMsg:
+		if r == nil || msg.id != r.id || !msg.found {
+			return nil
+		}
+		if !msg.leaveAt.Equal(r.leaveAt) {
+			log.Debug("reminder moved", "from", r.leaveAt, "to", msg.leaveAt)
+			r.leaveAt = msg.leaveAt
+		}
+	}
+
+	return nil
+}
+
+// refreshReminder replans the trip from the service's timetabled departure
+// and picks the reminder's journey back out, to catch it running late
+func (m *RootModel) refreshReminder(r *reminder) tea.Cmd {
+	client := m.Client
+	id, origin, destination, journey := r.id, r.originID, r.destinationID, r.journey
+	opts := api.TripOptions{At: r.departure}
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		journeys, err := client.TripPlan(ctx, origin, destination, opts)
+		if err != nil {
+			log.Debug("could not refresh reminder", "err", err)
+			return reminderRefreshMsg{id: id}
+		}
+		for _, j := range journeys {
+			if journeyKey(j) != journey {
+				continue
+			}
+			leaveAt, ok := j.LeaveAt()
+			return reminderRefreshMsg{id: id, leaveAt: leaveAt, found: ok}
+		}
+		return reminderRefreshMsg{id: id}
+	}
+}
+
+// notify rings the bell and raises a desktop notification, using both
+// OSC 9 (iTerm2, kitty, WezTerm) and OSC 777 (VTE, foot, rxvt)
+func (m *RootModel) notify(title string, body string) tea.Cmd {
+	title, body = oscText(title), oscText(body)
+	seq := "\a" +
+		"\x1b]9;" + body + "\a" +
+		"\x1b]777;notify;" + title + ";" + body + "\a"
+	return m.writeTerminal(seq, reminderNotifiedMsg{})
+}
+
+// oscText keeps text from ending the OSC sequence it's in early, or from
+// splitting OSC 777's title and body
+func oscText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ';':
+			return ','
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0):
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// parseReminderMinutes reads the prompt's answer
+func parseReminderMinutes(value string) (time.Duration, error) {
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("not a number of minutes: %q", value)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
@@ -1,85 +1,365 @@
 package ui
 
 import (
+	"fmt"
+	"maps"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
 	"github.com/isobelmcrae/trip/rendermaps"
 	"github.com/isobelmcrae/trip/styles"
 )
 
+// pins on the route map, drawn over the lines
+const (
+	markerColour      = "#ffffff" // a stop picked out, and interchanges
+	originColour      = "#00c853"
+	destinationColour = "#ff1744"
+
+	stopMarker        = '•'
+	interchangeMarker = '◆'
+	originMarker      = '●'
+	destinationMarker = '■'
+	selectedMarker    = '◉'
+)
+
 func (s *routeState) renderLeg(legs []api.Leg, legIdx int) {
+	renderLegMap(s.root, legs, s.mapLeg(legIdx), legMapOptions{
+		jmap:    s.journeyMap(s.paginator.Page),
+		view:    s.mapCtl.view,
+		overlay: s.mapOverlay(legs, legIdx),
+	})
+}
+
+// mapOverlay names the focused leg, for the top of the full screen map
+func (s *routeState) mapOverlay(legs []api.Leg, legIdx int) string {
+	if s.overview {
+		return fmt.Sprintf("Whole journey, %d legs", len(legs))
+	}
 	l := legs[legIdx]
-	
-	width, height := s.root.Main.GetWidth(), s.root.Main.GetHeight()
+	transport := format.LegTransport(l)
+	line := styles.CreateLineHighlight(transport).Render(fmt.Sprintf("[%s]", transport))
+	return fmt.Sprintf("%s %s → %s  (leg %d of %d)", line, format.LegOriginText(s.loc, l), format.LegDestinationText(s.loc, l), legIdx+1, len(legs))
+}
+
+// mapLeg is the leg the map focuses on, which is none in the overview
+func (s *routeState) mapLeg(legIdx int) int {
+	if s.overview {
+		return overviewLeg
+	}
+	return legIdx
+}
+
+// fitMap is where the map looks when the user hasn't moved it
+func (s *routeState) fitMap() mapView {
+	legs := s.Routes[s.paginator.Page].Legs
+	return fitLegMap(s.root, legs, s.journeyMap(s.paginator.Page).paths, s.mapLeg(s.legSelection))
+}
+
+// journeyMap is what the map draws for a journey, worked out once rather
+// than on every frame, as the paths come from the database
+type journeyMap struct {
+	paths  [][][2]float64
+	stops  [][]mapPin // each leg's stops, marked when it's bold
+	ends   []mapPin   // the interchanges, then where the journey starts and ends
+	legend []rendermaps.LegendEntry
+}
+
+// mapPin is a marker on the map, named when it has a label
+type mapPin struct {
+	lat, lon float64
+	colour   string
+	marker   rune
+	label    string
+}
+
+func newJourneyMap(client *api.TripClient, j api.Journey) *journeyMap {
+	m := &journeyMap{
+		paths:  client.JourneyPaths(j),
+		stops:  make([][]mapPin, len(j.Legs)),
+		legend: legendFor(j.Legs),
+	}
+	if len(j.Legs) == 0 {
+		return m
+	}
+
+	for i, l := range j.Legs {
+		if l.IsWalk() {
+			continue
+		}
+		hex := styles.HexColourForLine(format.LegTransport(l))
+		for _, stop := range l.StopSequence {
+			if len(stop.Coord) == 2 {
+				m.stops[i] = append(m.stops[i], mapPin{lat: stop.Coord[0], lon: stop.Coord[1], colour: hex, marker: stopMarker})
+			}
+		}
+	}
 
-	// focus on the leg's origin and destination
-	centerLat, centerLon, zoom := rendermaps.FocusOn(
-        l.Origin.Coord[0], l.Origin.Coord[1],
-        l.Destination.Coord[0], l.Destination.Coord[1],
-        width, height,
-    )
+	for _, l := range j.Legs[:len(j.Legs)-1] {
+		if at := l.Destination; len(at.Coord) == 2 {
+			m.ends = append(m.ends, mapPin{at.Coord[0], at.Coord[1], markerColour, interchangeMarker, at.DisassembledName})
+		}
+	}
+	if origin := j.Legs[0].Origin; len(origin.Coord) == 2 {
+		m.ends = append(m.ends, mapPin{origin.Coord[0], origin.Coord[1], originColour, originMarker, origin.DisassembledName})
+	}
+	if destination := j.Legs[len(j.Legs)-1].Destination; len(destination.Coord) == 2 {
+		m.ends = append(m.ends, mapPin{destination.Coord[0], destination.Coord[1], destinationColour, destinationMarker, destination.DisassembledName})
+	}
+	return m
+}
+
+// mapKey tells journeys apart by the way they go, so a refreshed journey
+// keeps its map
+func mapKey(j api.Journey) string {
+	var b strings.Builder
+	for _, l := range j.Legs {
+		fmt.Fprintf(&b, "%s|%s|%s|%d;", l.Transportation.ID, l.Origin.ID, l.Destination.ID, len(l.StopSequence))
+	}
+	return b.String()
+}
+
+// journeyMap is the map for the journey on page, worked out the first time
+// it's needed unless prefetchMaps got there first
+func (s *routeState) journeyMap(page int) *journeyMap {
+	key := mapKey(s.Routes[page])
+	if m, ok := s.maps[key]; ok {
+		return m
+	}
+	if s.maps == nil {
+		s.maps = map[string]*journeyMap{}
+	}
+	m := newJourneyMap(s.root.Client, s.Routes[page])
+	s.maps[key] = m
+	return m
+}
+
+// journeyMapsMsg carries the maps prefetchMaps worked out, by mapKey
+type journeyMapsMsg struct {
+	state *routeState
+	maps  map[string]*journeyMap
+}
+
+func (msg journeyMapsMsg) target() AppState { return msg.state }
+
+// addJourneyMaps keeps maps worked out in the background, and drops those
+// for journeys no longer shown
+func (s *routeState) addJourneyMaps(fetched map[string]*journeyMap) {
+	kept := map[string]*journeyMap{}
+	for _, j := range s.Routes {
+		key := mapKey(j)
+		if m, ok := s.maps[key]; ok {
+			kept[key] = m
+		} else if m, ok := fetched[key]; ok {
+			kept[key] = m
+		}
+	}
+	s.maps = kept
+}
+
+// fitLegMap fits the focused leg, or every leg, into the map's cell
+func fitLegMap(root *RootModel, legs []api.Leg, paths [][][2]float64, legIdx int) mapView {
+	return fitPaths(paths, legIdx, root.Main.GetWidth(), root.Main.GetHeight())
+}
+
+func fitPaths(paths [][][2]float64, legIdx int, width int, height int) mapView {
+	var points [][2]float64
+	for i, path := range paths {
+		if legIdx == overviewLeg || i == legIdx {
+			points = append(points, path...)
+		}
+	}
+	centerLat, centerLon, zoom := rendermaps.FocusOnPoints(points, width, height)
 
 	// we don't wanna zoom in further than this
 	if zoom > 14 {
 		zoom = 14
 	}
 
-	renderer := rendermaps.RenderMap(width - 4, height - 2, centerLat, centerLon, zoom)
+	return mapView{lat: centerLat, lon: centerLon, zoom: zoom}
+}
+
+// prefetchMaps loads the map tiles for each journey's overview and every
+// one of its legs in the background, the journey being looked at first,
+// so moving between them doesn't wait on the network. The journeys' maps
+// worked out along the way are kept for drawing them
+func (s *routeState) prefetchMaps() tea.Cmd {
+	if len(s.Routes) == 0 {
+		return nil
+	}
+
+	client := s.root.Client
+	known := maps.Clone(s.maps)
+	width, height := s.root.Main.GetWidth(), s.root.Main.GetHeight()
+	// the route view always names the leg above a full screen map
+	mapWidth, mapHeight := mapSize(s.root, true)
+	journeys := append([]api.Journey{s.Routes[s.paginator.Page]}, s.Routes...)
+
+	return func() tea.Msg {
+		var views []rendermaps.MapView
+		fetched := map[string]*journeyMap{}
+		for _, j := range journeys {
+			key := mapKey(j)
+			if _, ok := fetched[key]; ok {
+				continue
+			}
+			m, ok := known[key]
+			if !ok {
+				m = newJourneyMap(client, j)
+			}
+			fetched[key] = m
+
+			paths := m.paths
+			for legIdx := overviewLeg; legIdx < len(paths); legIdx++ {
+				v := fitPaths(paths, legIdx, width, height)
+				views = append(views, rendermaps.MapView{Lat: v.lat, Lon: v.lon, Zoom: v.zoom})
+			}
+		}
+
+		rendermaps.Prefetch(mapWidth, mapHeight, views)
+		return journeyMapsMsg{state: s, maps: fetched}
+	}
+}
+
+// passed as the leg to renderLegMap to fit the whole journey in
+const overviewLeg = -1
+
+type legMapOptions struct {
+	jmap    *journeyMap      // the journey's paths, see journeyMap
+	stop    *api.JourneyStop // marked when set
+	view    *mapView         // where to look, fitting the focused leg when nil
+	overlay string           // a line above the map when it's full screen
+}
+
+// mapSize is the map renderLegMap draws in Main, inside its border and
+// under the overlay's line when full screen
+func mapSize(root *RootModel, overlay bool) (int, int) {
+	width, height := root.Main.GetWidth()-4, root.Main.GetHeight()-2
+	if root.FullScreen && overlay {
+		height--
+	}
+	return width, height
+}
+
+// renderLegMap draws the journey's legs focused on legs[legIdx]. The
+// focused leg is drawn bold over the others, or all of them are for the
+// overview
+func renderLegMap(root *RootModel, legs []api.Leg, legIdx int, opts legMapOptions) {
+	width, height := mapSize(root, opts.overlay != "")
+
+	overlay := ""
+	if root.FullScreen && opts.overlay != "" {
+		overlay = lipgloss.NewStyle().MaxWidth(width).Render(opts.overlay)
+	}
+
+	stop, view := opts.stop, opts.view
+
+	paths := opts.jmap.paths
+	if view == nil {
+		fit := fitLegMap(root, legs, paths, legIdx)
+		view = &fit
+	}
+	centerLat, centerLon, zoom := view.lat, view.lon, view.zoom
+
+	renderer := rendermaps.RenderMap(width, height, centerLat, centerLon, zoom)
+
+	// the rest of the journey is drawn thin, and first so the map doesn't
+	// take over its colour
+	for i, path := range paths {
+		if legIdx != overviewLeg && i != legIdx {
+			renderPath(renderer, path, centerLat, centerLon, zoom, styles.HexColourForLine(format.LegTransport(legs[i])), false)
+		}
+	}
+
 	renderer.Draw([]string{"landuse", "water", "building", "road", "admin"})
 
-	// draw the leg as a red line
-	for leg := range legs {
-		// TODO(iso): make this actually do the colours of the transport type
-		//            this is just testing data for now
-		
-		var hex string
+	for i, path := range paths {
+		if legIdx == overviewLeg || i == legIdx {
+			renderPath(renderer, path, centerLat, centerLon, zoom, styles.HexColourForLine(format.LegTransport(legs[i])), true)
+		}
+	}
 
-		hex = styles.HexColourForLine(legs[leg].Transportation.DisassembledName)
-		
-		l := legs[leg]
-		renderPartLeg(s, renderer, l, centerLat, centerLon, zoom, hex)
+	renderPins(renderer, opts.jmap, legIdx)
+	if stop != nil && len(stop.Coord) == 2 {
+		renderer.Pin(stop.Coord[0], stop.Coord[1], markerColour, selectedMarker)
 	}
 
-	// but still draw the rest of the lines too
+	// but still draw the rest of the lines too, around the pins' labels
 	renderer.Draw([]string{"place_label", "poi_label"})
 
+	renderer.DrawOverlay(opts.jmap.legend)
+
 	frame := renderer.Frame()
+	if overlay != "" {
+		frame = overlay + "\n" + frame
+	}
 
-    s.root.Main.SetContent(
+    root.Main.SetContent(
 		lipgloss.JoinVertical(lipgloss.Right, lipgloss.JoinHorizontal(lipgloss.Center, frame)),
 	)
 }
 
-func renderPartLeg(
-	s *routeState,
-	renderer *rendermaps.Renderer, l api.Leg,
-	centerLat float64, centerLon float64, zoom float64, hex string,
-) {
-	// no more naive method
-	/* renderer.Canvas.SplatLineGeo(
-		l.Origin.Coord[0], l.Origin.Coord[1],
-		l.Destination.Coord[0], l.Destination.Coord[1],
-		centerLat, centerLon,
-		zoom, hex,
-	) */
-
-	for i := 1; i < len(l.StopSequence); i++ {
-		stop := l.StopSequence[i].ID
-		prevStop := l.StopSequence[i-1].ID
-
-		points, err := s.root.Client.GetJourneyLeg(prevStop, stop)
-		if err != nil {
-			continue // skip this leg if we can't find a path
+// legendFor lists each line the journey uses once, in the order it's taken
+func legendFor(legs []api.Leg) []rendermaps.LegendEntry {
+	var legend []rendermaps.LegendEntry
+	seen := map[string]bool{}
+	for _, l := range legs {
+		transport := format.LegTransport(l)
+		if seen[transport] {
+			continue
+		}
+		seen[transport] = true
+
+		name := transport
+		if l.IsWalk() {
+			name = "Walk"
+		}
+		legend = append(legend, rendermaps.LegendEntry{Name: name, Colour: styles.HexColourForLine(transport)})
+	}
+	return legend
+}
+
+// renderPins marks the stops along the bold legs, then where the journey
+// starts, changes and ends, naming the interchanges
+func renderPins(renderer *rendermaps.Renderer, jmap *journeyMap, legIdx int) {
+	for i, stops := range jmap.stops {
+		if legIdx != overviewLeg && i != legIdx {
+			continue
+		}
+		for _, pin := range stops {
+			renderer.Pin(pin.lat, pin.lon, pin.colour, pin.marker)
 		}
+	}
+
+	for _, pin := range jmap.ends {
+		renderer.Pin(pin.lat, pin.lon, pin.colour, pin.marker)
+		renderer.Label(pin.label, pin.lat, pin.lon, pin.colour)
+	}
+}
 
-		pointsLen := len(points)
-		for j := 0; j < pointsLen-1; j++ {
+// renderPath draws a leg's path, bold or as a thin line
+func renderPath(
+	renderer *rendermaps.Renderer, points [][2]float64,
+	centerLat float64, centerLon float64, zoom float64, hex string, bold bool,
+) {
+	for j := 0; j < len(points)-1; j++ {
+		if bold {
 			renderer.Canvas.SplatLineGeo(
 				points[j][0], points[j][1],
 				points[j+1][0], points[j+1][1],
 				centerLat, centerLon,
 				zoom, hex,
 			)
+		} else {
+			renderer.Canvas.LineGeo(
+				points[j][0], points[j][1],
+				points[j+1][0], points[j+1][1],
+				centerLat, centerLon,
+				zoom, hex,
+			)
 		}
 	}
 }
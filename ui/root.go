@@ -1,7 +1,6 @@
 package ui
 
 import (
-    "database/sql"
     "github.com/76creates/stickers/flexbox"
     tea "github.com/charmbracelet/bubbletea"
     "github.com/isobelmcrae/trip/styles"
@@ -22,14 +21,49 @@ type RootModel struct {
 
     OriginID string
     DestinationID string
+    OriginName string
+    DestinationName string
+
+    Favourites *state.Favourites
+    History *state.History
+
+    // when journeys are planned for, departing now unless set on launch
+    TripOptions api.TripOptions
+
+    // true when served over SSH, where files written would stay on the server
+    Remote bool
+    // escape sequences waiting to go out with the next frame, see terminal.go
+    terminalSeqs []terminalSeq
+
+    // the "leave now" reminder, if one is armed
+    reminder *reminder
+    reminderSeq int
+
+    // set by Launch, consumed once the terminal's size is known
+    pendingLaunch *LaunchOptions
+    // set by launch, consumed once the origin is known
+    pendingTo string
+    pendingDepartures bool
 
     Sidebar *flexbox.Cell
     Main *flexbox.Cell
+
+    // the map on its own, shown in place of flexBox when FullScreen is set.
+    // Main is its only cell while it's showing
+    fullMap *flexbox.FlexBox
+    FullScreen bool
+}
+
+// states that can show their map full screen
+type fullScreenMap interface {
+    canFullScreen()
 }
 
-func InitialiseRootModel() (m *RootModel){
+// userDir holds the user's favourites and history, see state.ConfigDir
+// and state.SSHUserDir. Nothing is saved if it's empty
+func InitialiseRootModel(userDir string) (m *RootModel){
     // figure out what to do with this + other strings
-    var welcome = "trip v0.0.1\n\nsydney public transport for your terminal\n\nhjkl/arrow keys to move\nesc to go back, enter to select\nctrl+c to exit"
+    var welcome = "trip v0.0.1\n\nsydney public transport for your terminal\n\nhjkl/arrow keys to move\nesc to go back, enter to select\ns to save a favourite, up/down for recent searches\nr to plan the return trip\nn to be reminded when to leave\nctrl+c to exit"
 
     // create base flexbox cells
     m = &RootModel {
@@ -47,15 +81,35 @@ func InitialiseRootModel() (m *RootModel){
 
     m.Sidebar = m.flexBox.GetRow(0).GetCell(1)
     m.Main = m.flexBox.GetRow(0).GetCell(0)
+
+    m.fullMap = flexbox.New(0, 0)
+    m.fullMap.AddRows([]*flexbox.Row{
+        m.fullMap.NewRow().AddCells(flexbox.NewCell(1, 1).SetStyle(styles.Border)),
+    })
     
-    db, err := sql.Open("sqlite3", state.DatabasePath)
+    db, err := api.OpenDatabase(state.DatabasePath)
     if err != nil {
         log.Fatal(err)
     }
     // defer db.Close()
     m.Client = api.NewClient(db)
 
-    m.States.Push(newOriginInputState(m))
+    m.Favourites, err = state.OpenFavourites(userDir)
+    if err != nil {
+        log.Error("could not load favourites", "err", err)
+        welcome += "\n\ncould not load favourites: " + err.Error()
+    }
+    m.History, err = state.OpenHistory(userDir)
+    if err != nil {
+        log.Error("could not load history", "err", err)
+        welcome += "\n\ncould not load history: " + err.Error()
+    }
+
+    if m.Favourites.IsEmpty() {
+        m.States.Push(newOriginInputState(m))
+    } else {
+        m.States.Push(newFavouritesState(m))
+    }
 
     main := styles.WelcomeMainContent.Render(welcome)
     m.flexBox.GetRow(0).GetCell(0).SetContent(main).
@@ -64,19 +118,48 @@ func InitialiseRootModel() (m *RootModel){
     return m
 }
 
+// SetFullScreen gives the whole terminal to the map, or puts the sidebar back
+func (m *RootModel) SetFullScreen(on bool) {
+    m.FullScreen = on
+    if on {
+        m.Main = m.fullMap.GetRow(0).GetCell(0)
+    } else {
+        m.Main = m.flexBox.GetRow(0).GetCell(0)
+    }
+}
+
+// SwapOriginAndDestination turns the trip around, for planning the way home
+func (m *RootModel) SwapOriginAndDestination() {
+    m.OriginID, m.DestinationID = m.DestinationID, m.OriginID
+    m.OriginName, m.DestinationName = m.DestinationName, m.OriginName
+}
+
 func (m *RootModel) Init() tea.Cmd {
     return tea.Batch(
         tea.SetWindowTitle("trip"),
+        initState(m.States.Peek()),
     )
 }
 
 func (m *RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
     var cmd tea.Cmd
+    stateBefore := m.States.Peek()
 
     switch msg := msg.(type) {
     case tea.WindowSizeMsg:
         m.flexBox.SetWidth(msg.Width)
         m.flexBox.SetHeight(msg.Height)
+        m.fullMap.SetWidth(msg.Width)
+        m.fullMap.SetHeight(msg.Height)
+        // cells are only resized when rendered, but states size themselves
+        // from them as they're made
+        m.flexBox.ForceRecalculate()
+        m.fullMap.ForceRecalculate()
+
+        if opts := m.pendingLaunch; opts != nil {
+            m.pendingLaunch = nil
+            m.launch(*opts)
+        }
     case tea.KeyMsg:
         switch msg.Type {
         case tea.KeyCtrlC:
@@ -87,28 +170,75 @@ func (m *RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         }
     }
 
+    switch msg := msg.(type) {
+    case reminderTickMsg, reminderRefreshMsg:
+        return m, m.updateReminder(msg)
+    case targetedMsg:
+        return m, m.updateTarget(msg.target(), msg)
+    }
+
     current := m.States.Peek()
     if current == nil {
         return m, nil
     }
+    // a state pushed above, e.g. by the launch, hasn't been started yet
+    if current != stateBefore {
+        cmd = initState(current)
+    }
     
     oldStateSize := m.States.Size()
-    updatedState, cmd := current.Update(msg)
+    updatedState, updateCmd := current.Update(msg)
+    cmd = tea.Batch(cmd, updateCmd)
     newStateSize := m.States.Size()
 
     if oldStateSize == newStateSize {
         m.States.states[len(m.States.states) - 1] = updatedState
     }
 
-    m.View()
+    // start anything a newly shown state needs
+    if top := m.States.Peek(); top != current {
+        cmd = tea.Batch(cmd, initState(top))
+    }
+
+    m.render()
+
+    return m, tea.Batch(cmd, m.scheduleReminder())
+}
 
-    return m, cmd
+// updateTarget hands msg to target wherever it is in the stack, dropping
+// it if target has since been popped
+func (m *RootModel) updateTarget(target AppState, msg tea.Msg) tea.Cmd {
+    for i, state := range m.States.states {
+        if state != target {
+            continue
+        }
+        updated, cmd := state.Update(msg)
+        m.States.states[i] = updated
+        m.render()
+        return cmd
+    }
+    return nil
 }
 
+// View draws the frame, sending any queued escape sequences with it
 func (m *RootModel) View() string {
+    return m.takeTerminalSeqs() + m.render()
+}
+
+// render draws the top state into the cells
+func (m *RootModel) render() string {
     state := m.States.Peek()
+
+    // only states with a map can have it full screen, e.g. after going back
+    if _, ok := state.(fullScreenMap); m.FullScreen && !ok {
+        m.SetFullScreen(false)
+    }
+
     if state != nil {
         state.RenderCells(m.flexBox)
     }
+    if m.FullScreen {
+        return m.fullMap.Render()
+    }
     return m.flexBox.Render()
 }
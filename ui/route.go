@@ -16,6 +16,8 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
+	"github.com/isobelmcrae/trip/state"
 	"github.com/isobelmcrae/trip/styles"
 )
 
@@ -30,6 +32,34 @@ var legSelectionKeymapDefault = legSelectionKeymap{
 	NextLeg: key.NewBinding(key.WithKeys("down", "j")),
 }
 
+type routeActionKeymap struct {
+	SaveTrip      key.Binding
+	SwapTrip      key.Binding
+	ExportICS     key.Binding
+	ExportICSTrip key.Binding
+	ExportGeoJSON key.Binding
+	ExportGPX     key.Binding
+	Copy          key.Binding
+	Remind        key.Binding
+	CancelRemind  key.Binding
+	ExpandLeg     key.Binding
+	Compare       key.Binding
+}
+
+var routeActionKeymapDefault = routeActionKeymap{
+	SaveTrip:      key.NewBinding(key.WithKeys("s")),
+	SwapTrip:      key.NewBinding(key.WithKeys("r")),
+	ExportICS:     key.NewBinding(key.WithKeys("i")), // an event per leg
+	ExportICSTrip: key.NewBinding(key.WithKeys("I")), // one event for the whole trip
+	ExportGeoJSON: key.NewBinding(key.WithKeys("e")),
+	ExportGPX:     key.NewBinding(key.WithKeys("E")),
+	Copy:          key.NewBinding(key.WithKeys("c")),
+	Remind:        key.NewBinding(key.WithKeys("n")),
+	CancelRemind:  key.NewBinding(key.WithKeys("N")),
+	ExpandLeg:     key.NewBinding(key.WithKeys("enter")), // list the focused leg's stops
+	Compare:       key.NewBinding(key.WithKeys("t")),     // all journeys in a table
+}
+
 // routeState holds the state for the route view.
 type routeState struct {
 	root         *RootModel
@@ -39,8 +69,20 @@ type routeState struct {
 	legWidth     int
 	loc          *time.Location
 	legSelection int
-	legOffsets   []int // Track vertical positions of each leg
-	legHeights   []int // Track actual heights of each leg
+	overview     bool // the whole journey is focused rather than a leg
+	mapCtl       mapControl
+	legOffsets   []int  // Track vertical positions of each leg
+	legHeights   []int  // Track actual heights of each leg
+	status       string // feedback from the last action, cleared on the next key
+
+	// realtime refreshes, see routeTickMsg
+	ticking    bool
+	refreshing bool
+	refreshed  time.Time
+	changed    map[string]bool // times moved by the last refresh, see timeKey
+	dirty      bool            // refreshed while covered, redrawn once back on top
+
+	maps map[string]*journeyMap // by mapKey, see journeyMap
 
 	// Smooth scrolling state
 	targetYOffset   int
@@ -48,10 +90,33 @@ type routeState struct {
 	smoothScrolling bool // Whether smooth scrolling is enabled
 }
 
+const (
+	// how often countdowns are redrawn and departed journeys dropped
+	routeTickInterval = 15 * time.Second
+	// how often journeys are fetched again for fresh realtime estimates
+	routeRefreshInterval = 45 * time.Second
+)
+
+type routeTickMsg struct{ state *routeState }
+
+func (msg routeTickMsg) target() AppState { return msg.state }
+
+// routesFetchedMsg carries journeys fetched in the background for a trip,
+// which is stale if the user has since turned the trip around
+type routesFetchedMsg struct {
+	state         *routeState
+	originID      string
+	destinationID string
+	journeys      []api.Journey
+	err           error
+}
+
+func (msg routesFetchedMsg) target() AppState { return msg.state }
+
 // getRoutes fetches trip plans from the API.
 func (s *routeState) getRoutes() []api.Journey {
 	// TODO: handle req which take a long time
-	routes, err := s.root.Client.TripPlan(context.TODO(), s.root.OriginID, s.root.DestinationID)
+	routes, err := s.root.Client.TripPlan(context.TODO(), s.root.OriginID, s.root.DestinationID, s.root.TripOptions)
 	if err != nil {
 		log.Debug("Error when fetching routes", "err", err)
 	}
@@ -89,20 +154,6 @@ func newRouteState(root *RootModel) AppState {
 		smoothScrolling: smoothScrolling,
 	}
 
-	originalRoutes := s.getRoutes()
-
-	// Filter routes to only include future journeys.
-	now := time.Now()
-	for _, route := range originalRoutes {
-		if len(route.Legs) > 0 {
-			routeStartTime := route.Legs[0].Origin.DepartureTimeEstimated
-			parsedTime, err := time.Parse(time.RFC3339, routeStartTime)
-			if err == nil && parsedTime.After(now) {
-				s.Routes = append(s.Routes, route)
-			}
-		}
-	}
-
 	// measurements are relative to root's flexbox
 	bigWidth := s.root.flexBox.GetWidth()
 	width := int(math.Floor(float64(bigWidth)/10)*3) - 6
@@ -122,16 +173,217 @@ func newRouteState(root *RootModel) AppState {
 	s.paginator.PerPage = 1
 	s.paginator.ActiveDot = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "235", Dark: "252"}).PaddingRight(1).Render("⬤")
 	s.paginator.InactiveDot = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "250", Dark: "238"}).PaddingRight(1).Render("⬤")
+
+	s.loadRoutes()
+
+	return s
+}
+
+func (s *routeState) canFullScreen() {}
+
+// Init starts the realtime refresh ticking, and prefetches the maps of
+// the journeys first loaded
+func (s *routeState) Init() tea.Cmd {
+	if s.dirty {
+		s.dirty = false
+		s.RenderCells(s.root.flexBox)
+	}
+	if s.ticking {
+		return nil
+	}
+	s.ticking = true
+	return tea.Batch(s.tick(), s.prefetchMaps())
+}
+
+// redraw renders the state if it's on top, otherwise leaves it for Init, so
+// the state covering it keeps its cells
+func (s *routeState) redraw() {
+	if s.root.States.Peek() != s {
+		s.dirty = true
+		return
+	}
+	s.RenderCells(s.root.flexBox)
+}
+
+func (s *routeState) tick() tea.Cmd {
+	return tea.Tick(routeTickInterval, func(time.Time) tea.Msg {
+		return routeTickMsg{state: s}
+	})
+}
+
+// fetchRoutes plans the trip again in the background
+func (s *routeState) fetchRoutes() tea.Cmd {
+	client, origin, destination, opts := s.root.Client, s.root.OriginID, s.root.DestinationID, s.root.TripOptions
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		journeys, err := client.TripPlan(ctx, origin, destination, opts)
+		return routesFetchedMsg{state: s, originID: origin, destinationID: destination, journeys: journeys, err: err}
+	}
+}
+
+// timeKey names one of a journey's times, stable across refreshes
+func timeKey(j api.Journey, leg int, end string) string {
+	return fmt.Sprintf("%s|%d|%s", journeyKey(j), leg, end)
+}
+
+// applyRefresh swaps in freshly fetched journeys, noting which times moved
+// and staying on the journey and leg the user was looking at. It reports
+// whether any of the journeys weren't shown before
+func (s *routeState) applyRefresh(journeys []api.Journey) bool {
+	previous := map[string]api.Journey{}
+	for _, j := range s.Routes {
+		previous[journeyKey(j)] = j
+	}
+
+	added := false
+	s.changed = map[string]bool{}
+	for _, j := range journeys {
+		old, ok := previous[journeyKey(j)]
+		if !ok {
+			added = true
+			continue
+		}
+		for i := range min(len(j.Legs), len(old.Legs)) {
+			if j.Legs[i].Origin.DepartureTimeEstimated != old.Legs[i].Origin.DepartureTimeEstimated {
+				s.changed[timeKey(j, i, "dep")] = true
+			}
+			if j.Legs[i].Destination.ArrivalTimeEstimated != old.Legs[i].Destination.ArrivalTimeEstimated {
+				s.changed[timeKey(j, i, "arr")] = true
+			}
+		}
+	}
+
+	s.replaceRoutes(s.root.TripOptions.Upcoming(journeys, time.Now()))
+	return added
+}
+
+// replaceRoutes shows journeys in place of the current ones, keeping the
+// focused journey and leg if they're still there
+func (s *routeState) replaceRoutes(journeys []api.Journey) {
+	var focused string
+	if s.paginator.Page < len(s.Routes) {
+		focused = journeyKey(s.Routes[s.paginator.Page])
+	}
+
+	s.Routes = journeys
 	s.paginator.SetTotalPages(len(s.Routes))
 
+	page := -1
+	for i, j := range s.Routes {
+		if journeyKey(j) == focused {
+			page = i
+			break
+		}
+	}
+	if page < 0 {
+		s.paginator.Page = 0
+		s.focusOverview()
+		s.viewport.GotoTop()
+	} else {
+		s.paginator.Page = page
+		s.legSelection = min(s.legSelection, len(s.Routes[page].Legs)-1)
+	}
+
+	if len(s.Routes) == 0 {
+		s.viewport.SetContent(lipgloss.NewStyle().Width(s.legWidth).Align(lipgloss.Center).Render("No routes found."))
+	} else {
+		s.setViewportContent(s.paginator.Page)
+	}
+}
+
+// loadRoutes fetches journeys for the root's origin and destination,
+// replacing whatever is shown and starting again from the first journey
+func (s *routeState) loadRoutes() {
+	err := s.root.History.AddTrip(state.HistoryTrip{
+		OriginID:        s.root.OriginID,
+		OriginName:      s.root.OriginName,
+		DestinationID:   s.root.DestinationID,
+		DestinationName: s.root.DestinationName,
+		At:              time.Now(),
+	})
+	if err != nil {
+		log.Error("could not save trip history", "err", err)
+	}
+
+	// Filter routes to only include future journeys.
+	s.Routes = s.root.TripOptions.Upcoming(s.getRoutes(), time.Now())
+	s.refreshed = time.Now()
+	s.changed = nil
+
+	s.focusOverview()
+	s.paginator.Page = 0
+	s.paginator.SetTotalPages(len(s.Routes))
+	s.viewport.GotoTop()
+
 	// Set initial content, handling the no-routes case.
 	if len(s.Routes) == 0 {
 		s.viewport.SetContent(lipgloss.NewStyle().Width(s.legWidth).Align(lipgloss.Center).Render("No routes found."))
 	} else {
 		s.setViewportContent(0)
 	}
+}
 
-	return s
+// swapTrip plans the return trip in place
+func (s *routeState) swapTrip() {
+	s.root.SwapOriginAndDestination()
+	s.loadRoutes()
+}
+
+// newSaveTripState asks for a name and saves the current origin and destination
+func (s *routeState) newSaveTripState() AppState {
+	trip := state.SavedTrip{
+		OriginID:        s.root.OriginID,
+		OriginName:      s.root.OriginName,
+		DestinationID:   s.root.DestinationID,
+		DestinationName: s.root.DestinationName,
+	}
+	defaultName := fmt.Sprintf("%s → %s", trip.OriginName, trip.DestinationName)
+
+	return newPromptState(s.root, "Save trip as", defaultName, func(name string) error {
+		trip.Name = name
+		return s.root.Favourites.AddTrip(trip)
+	})
+}
+
+// newReminderState asks how much warning the user wants before they need
+// to leave for the focused journey
+func (s *routeState) newReminderState() AppState {
+	journey := s.Routes[s.paginator.Page]
+
+	return newPromptState(s.root, "Remind me how many minutes before I leave?", "5", func(value string) error {
+		before, err := parseReminderMinutes(value)
+		if err != nil {
+			return err
+		}
+		return s.root.armReminder(journey, before)
+	})
+}
+
+// reminderText shows when the armed reminder will have the user leave
+func (s *routeState) reminderText() string {
+	r := s.root.reminder
+	if r == nil {
+		return ""
+	}
+	return fmt.Sprintf("Leave at %s for the %s, reminding you %dmin before", r.leaveAt.In(s.loc).Format("3:04pm"), r.service, int(r.before.Minutes()))
+}
+
+// focusOverview shows the whole journey, ready to step through from its
+// first leg
+func (s *routeState) focusOverview() {
+	s.overview = true
+	s.legSelection = 0
+}
+
+// showJourney pages to one of the journeys, from its overview
+func (s *routeState) showJourney(idx int) {
+	s.paginator.Page = idx
+	s.focusOverview()
+	s.setViewportContent(idx)
+	s.viewport.GotoTop()
 }
 
 // setViewportContent sets the viewport content and calculates leg offsets
@@ -260,17 +512,34 @@ func (s *routeState) displayRouteWithOffsetsAndHeights(r api.Journey) (string, [
 		return "This journey has no legs.", []int{}, []int{}
 	}
 
+	// Wrap the title text to fit within the leg width
 	origin := r.Legs[0].Origin
 	destination := r.Legs[len(r.Legs)-1].Destination
 
-	// Wrap the title text to fit within the leg width
-	originText := fmt.Sprintf("%s @%s", origin.DisassembledName, formatTime(s.loc, origin.DepartureTimeEstimated))
-	destText := fmt.Sprintf("%s @%s", destination.DisassembledName, formatTime(s.loc, destination.ArrivalTimeEstimated))
+	departureBadge, arrivalBadge, cancelled := journeyBadges(r)
+
+	originText := fmt.Sprintf("%s @%s", origin.DisassembledName, s.timeText(timeKey(r, 0, "dep"), origin.DepartureTimeEstimated, cancelled))
+	if countdown := formatCountdown(time.Now(), origin.DepartureTimeEstimated); countdown != "" && !cancelled {
+		originText += fmt.Sprintf(" (leaves %s)", countdown)
+	}
+	if departureBadge != "" {
+		originText += " " + departureBadge
+	}
+	destText := fmt.Sprintf("%s @%s", destination.DisassembledName, s.timeText(timeKey(r, len(r.Legs)-1, "arr"), destination.ArrivalTimeEstimated, cancelled))
+	if arrivalBadge != "" {
+		destText += " " + arrivalBadge
+	}
 
 	wrappedOrigin := lipgloss.NewStyle().Width(s.legWidth).Render(originText)
 	wrappedDest := lipgloss.NewStyle().Width(s.legWidth).Render(destText)
 
 	title := fmt.Sprintf("%s\n\n%s\n\n", wrappedOrigin, wrappedDest)
+	if timeline := journeyOwnTimeline(r, s.legWidth); timeline != "" {
+		title += timeline + "\n\n"
+	}
+	if s.overview {
+		title += lipgloss.NewStyle().Faint(true).Width(s.legWidth).Render("Whole journey, down to step through each leg") + "\n\n"
+	}
 	doc.WriteString(title)
 
 	// Count lines in title for offset calculation
@@ -279,7 +548,7 @@ func (s *routeState) displayRouteWithOffsetsAndHeights(r api.Journey) (string, [
 
 	for idx, leg := range r.Legs {
 		offsets = append(offsets, currentOffset)
-		legContent := s.formatLeg(leg, idx)
+		legContent := s.formatLeg(r, leg, idx)
 		doc.WriteString(legContent)
 
 		// Calculate actual height of this leg by counting newlines in the rendered content
@@ -291,34 +560,37 @@ func (s *routeState) displayRouteWithOffsetsAndHeights(r api.Journey) (string, [
 	return doc.String(), offsets, heights
 }
 
-// formatTime converts a time string to a readable format.
-func formatTime(loc *time.Location, rawTime string) string {
-	if rawTime == "" {
-		return "n/a"
+// timeText formats a time, highlighted if the last refresh moved it and
+// struck through if the service isn't running
+func (s *routeState) timeText(key string, rawTime string, cancelled bool) string {
+	text := format.Time(s.loc, rawTime)
+	if cancelled {
+		return styles.Struck.Render(text)
 	}
-	parsed, err := time.Parse(time.RFC3339, rawTime)
-	if err != nil {
-		return "invalid time"
+	if s.changed[key] {
+		return styles.ChangedTime.Render(text)
 	}
-	return parsed.In(loc).Format("3:04pm")
+	return text
 }
 
 // formatLeg formats the display for a single leg of a journey.
-func (s *routeState) formatLeg(l api.Leg, idx int) string {
-	var transport string
-	if l.Transportation.DisassembledName == "" {
-		transport = "WALK"
-	} else {
-		transport = l.Transportation.DisassembledName
-	}
+func (s *routeState) formatLeg(r api.Journey, l api.Leg, idx int) string {
+	transport := format.LegTransport(l)
 
 	lineStr := styles.CreateLineHighlight(transport).Render(fmt.Sprintf("[%s]", transport))
-	originStr := fmt.Sprintf("%s %s | %s", lineStr, l.Origin.DisassembledName, formatTime(s.loc, l.Origin.DepartureTimeEstimated))
-	destStr := fmt.Sprintf("%s %s | %s", lineStr, l.Destination.DisassembledName, formatTime(s.loc, l.Destination.ArrivalTimeEstimated))
-	duration := l.Duration / 60
+	cancelled := l.IsCancelled()
+
+	originStr := fmt.Sprintf("%s %s | %s", lineStr, l.Origin.DisassembledName, s.timeText(timeKey(r, idx, "dep"), l.Origin.DepartureTimeEstimated, cancelled))
+	if countdown := formatCountdown(time.Now(), l.Origin.DepartureTimeEstimated); countdown != "" && !cancelled {
+		originStr += fmt.Sprintf(" (%s)", countdown)
+	}
+	if badge := legBadge(l); badge != "" {
+		originStr += " " + badge
+	}
+	destStr := fmt.Sprintf("%s %s | %s", lineStr, l.Destination.DisassembledName, s.timeText(timeKey(r, idx, "arr"), l.Destination.ArrivalTimeEstimated, cancelled))
 
 	var showSelectedStr string
-	isSelected := idx == s.legSelection
+	isSelected := idx == s.legSelection && !s.overview
 	if isSelected {
 		showSelectedStr = " (focused)"
 	}
@@ -334,7 +606,7 @@ func (s *routeState) formatLeg(l api.Leg, idx int) string {
 		}
 	}
 
-	leg := fmt.Sprintf("%s\n\n> Travel for %dmin%s%s\n\n%s", originStr, duration, showSelectedStr, positionLabel, destStr)
+	leg := fmt.Sprintf("%s\n\n> %s%s%s\n\n%s", originStr, format.LegTravelText(l), showSelectedStr, positionLabel, destStr)
 
 	return styles.FormatRouteLeg(s.legWidth, transport, isSelected).Render(leg) + "\n"
 }
@@ -352,6 +624,13 @@ func (s *routeState) RenderCells(f *flexbox.FlexBox) {
 		finalView = s.viewport.View()
 	}
 
+	if reminder := s.reminderText(); reminder != "" {
+		finalView = lipgloss.JoinVertical(lipgloss.Left, finalView, lipgloss.NewStyle().Width(s.legWidth).Render(reminder))
+	}
+	if s.status != "" {
+		finalView = lipgloss.JoinVertical(lipgloss.Left, finalView, lipgloss.NewStyle().Width(s.legWidth).Faint(true).Render(s.status))
+	}
+
 	s.root.Sidebar.SetContent(finalView)
 
 	// TODO render map here
@@ -367,8 +646,58 @@ func (s *routeState) Update(msg tea.Msg) (AppState, tea.Cmd) {
 
 	pageBefore := s.paginator.Page
 	legSelectionBefore := s.legSelection
+	overviewBefore := s.overview
+
+	// map keys and the mouse over the map move it about
+	if len(s.Routes) > 0 && s.paginator.Page < len(s.Routes) && s.mapCtl.Update(msg, s.root, s.fitMap) {
+		s.status = ""
+		s.RenderCells(s.root.flexBox)
+		return s, nil
+	}
 
 	switch msg := msg.(type) {
+	case clipboardCopiedMsg:
+		s.status = "Copied journey to clipboard"
+
+	case reminderNotifiedMsg:
+		s.status = "Time to leave!"
+
+	case journeyMapsMsg:
+		s.addJourneyMaps(msg.maps)
+		return s, nil
+
+	case routeTickMsg:
+		cmds = append(cmds, s.tick())
+
+		// drop journeys that have left, and redraw the countdowns
+		s.replaceRoutes(s.root.TripOptions.Upcoming(s.Routes, time.Now()))
+
+		if !s.refreshing && time.Since(s.refreshed) >= routeRefreshInterval {
+			s.refreshing = true
+			cmds = append(cmds, s.fetchRoutes())
+		}
+
+		// replaceRoutes has kept the focus, skip the page change handling below
+		s.redraw()
+		return s, tea.Batch(cmds...)
+
+	case routesFetchedMsg:
+		s.refreshing = false
+		s.refreshed = time.Now()
+		if msg.originID != s.root.OriginID || msg.destinationID != s.root.DestinationID {
+			break
+		}
+		if msg.err != nil {
+			log.Debug("Error when refreshing routes", "err", msg.err)
+			break
+		}
+		var cmd tea.Cmd
+		if s.applyRefresh(msg.journeys) {
+			cmd = s.prefetchMaps()
+		}
+		s.redraw()
+		return s, cmd
+
 	case smoothScrollMsg:
 		// Handle smooth scrolling animation only if smooth scrolling is enabled
 		if s.smoothScrolling {
@@ -402,18 +731,70 @@ func (s *routeState) Update(msg tea.Msg) (AppState, tea.Cmd) {
 		return s, tea.Batch(cmds...)
 
 	case tea.KeyMsg:
+		s.status = ""
+
 		// Handle leg selection keys first
 		switch {
 		case key.Matches(msg, legSelectionKeymapDefault.NextLeg):
 			if len(s.Routes) > 0 && s.paginator.Page < len(s.Routes) {
 				maxLeg := len(s.Routes[s.paginator.Page].Legs) - 1
-				if s.legSelection < maxLeg {
+				if s.overview {
+					s.overview = false
+				} else if s.legSelection < maxLeg {
 					s.legSelection++
 				}
 			}
 		case key.Matches(msg, legSelectionKeymapDefault.PrevLeg):
+			// the overview comes before the first leg
 			if s.legSelection > 0 {
 				s.legSelection--
+			} else {
+				s.overview = true
+			}
+		case key.Matches(msg, routeActionKeymapDefault.SaveTrip):
+			s.root.States.Push(s.newSaveTripState())
+			return s, nil
+		case key.Matches(msg, routeActionKeymapDefault.SwapTrip):
+			s.swapTrip()
+			return s, s.prefetchMaps()
+		case key.Matches(msg, routeActionKeymapDefault.ExportICS):
+			s.exportJourney("ics", writeICSLegs)
+		case key.Matches(msg, routeActionKeymapDefault.ExportICSTrip):
+			s.exportJourney("ics", writeICSWholeTrip)
+		case key.Matches(msg, routeActionKeymapDefault.ExportGeoJSON):
+			s.exportJourney("geojson", s.writeGeoJSON)
+		case key.Matches(msg, routeActionKeymapDefault.ExportGPX):
+			s.exportJourney("gpx", s.writeGPX)
+		case key.Matches(msg, routeActionKeymapDefault.Copy):
+			if len(s.Routes) > 0 && s.paginator.Page < len(s.Routes) {
+				cmds = append(cmds, s.root.copyToClipboard(format.JourneySummaryText(s.loc, s.Routes[s.paginator.Page])))
+			}
+		case key.Matches(msg, routeActionKeymapDefault.Remind):
+			if len(s.Routes) > 0 && s.paginator.Page < len(s.Routes) {
+				s.root.States.Push(s.newReminderState())
+				return s, nil
+			}
+		case key.Matches(msg, routeActionKeymapDefault.ExpandLeg):
+			if len(s.Routes) > 0 && s.paginator.Page < len(s.Routes) {
+				legs := s.Routes[s.paginator.Page].Legs
+				if s.overview {
+					s.status = "Pick a leg to list its stops"
+				} else if len(legs[s.legSelection].StopSequence) == 0 {
+					s.status = "No stops listed for this leg"
+				} else {
+					s.root.States.Push(newLegStopsState(s.root, legs, s.legSelection, s.loc, s.journeyMap(s.paginator.Page)))
+					return s, nil
+				}
+			}
+		case key.Matches(msg, routeActionKeymapDefault.Compare):
+			if len(s.Routes) > 0 {
+				s.root.States.Push(newCompareState(s.root, s))
+				return s, nil
+			}
+		case key.Matches(msg, routeActionKeymapDefault.CancelRemind):
+			if s.root.reminder != nil {
+				s.root.cancelReminder()
+				s.status = "Reminder cancelled"
 			}
 		default:
 			// For pagination and viewport scrolling (left/right arrows, page up/down)
@@ -434,17 +815,19 @@ func (s *routeState) Update(msg tea.Msg) (AppState, tea.Cmd) {
 
 	// After any potential update, check if the page has changed.
 	if len(s.Routes) > 0 && s.paginator.Page != pageBefore {
-		s.legSelection = 0 // Reset leg selection when changing routes
+		s.focusOverview() // Reset leg selection when changing routes
+		s.mapCtl.Reset()
 		s.setViewportContent(s.paginator.Page)
 		s.viewport.GotoTop()
-	} else if len(s.Routes) > 0 && s.legSelection != legSelectionBefore {
+	} else if len(s.Routes) > 0 && (s.legSelection != legSelectionBefore || s.overview != overviewBefore) {
 		// Leg selection changed, update content and scroll to selected leg
+		s.mapCtl.Reset()
 		s.setViewportContent(s.paginator.Page)
 		if cmd := s.scrollToSelectedLeg(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
 	}
 
-	s.RenderCells(s.root.flexBox)
+	s.redraw()
 	return s, tea.Batch(cmds...)
 }
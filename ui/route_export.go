@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/export"
+	"github.com/isobelmcrae/trip/state"
+)
+
+// exportJourney writes the journey on screen to state.ExportDir, reporting
+// where it went in the status line
+func (s *routeState) exportJourney(ext string, write func(io.Writer, api.Journey) error) {
+	if len(s.Routes) == 0 || s.paginator.Page >= len(s.Routes) {
+		return
+	}
+	// the file would land on the server, not with the user
+	if s.root.Remote {
+		s.status = "Exporting isn't available over SSH"
+		return
+	}
+
+	journey := s.Routes[s.paginator.Page]
+	path := filepath.Join(state.ExportDir(), export.FileName(journey, ext))
+
+	if err := writeExport(path, journey, write); err != nil {
+		log.Error("could not export journey", "path", path, "err", err)
+		s.status = fmt.Sprintf("Export failed: %v", err)
+		return
+	}
+
+	log.Debug("exported journey", "path", path)
+	s.status = "Saved " + path
+}
+
+func writeExport(path string, journey api.Journey, write func(io.Writer, api.Journey) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, journey); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeICSLegs(w io.Writer, j api.Journey) error {
+	return export.WriteICS(w, j, export.ICSOptions{})
+}
+
+func writeICSWholeTrip(w io.Writer, j api.Journey) error {
+	return export.WriteICS(w, j, export.ICSOptions{WholeTrip: true})
+}
+
+func (s *routeState) writeGeoJSON(w io.Writer, j api.Journey) error {
+	return export.WriteGeoJSON(w, j, s.root.Client.JourneyPaths(j))
+}
+
+func (s *routeState) writeGPX(w io.Writer, j api.Journey) error {
+	return export.WriteGPX(w, j, s.root.Client.JourneyPaths(j))
+}
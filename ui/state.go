@@ -16,6 +16,26 @@ type AppState interface {
     RenderCells(*flexbox.FlexBox)
 }
 
+// a state that starts commands when first shown, e.g. timers. Init may
+// be called again when the state is uncovered, so it should only start
+// things once
+type stateIniter interface {
+    Init() tea.Cmd
+}
+
+// a message meant for one state, delivered even when another state has
+// been pushed on top of it so that e.g. its timers keep running
+type targetedMsg interface {
+    target() AppState
+}
+
+func initState(state AppState) tea.Cmd {
+    if i, ok := state.(stateIniter); ok {
+        return i.Init()
+    }
+    return nil
+}
+
 // Set state as the current app state
 func (s *StateStack) Push(state AppState) {
     s.states = append(s.states, state)
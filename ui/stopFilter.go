@@ -0,0 +1,80 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/isobelmcrae/trip/api"
+    "github.com/isobelmcrae/trip/state"
+    "github.com/isobelmcrae/trip/styles"
+)
+
+type stopFilterKeymap struct {
+    NextMode key.Binding
+    PrevMode key.Binding
+    Save key.Binding
+    Platforms key.Binding
+}
+
+// tab/shift+tab cycle through the mode filters
+var stopFilterKeymapDefault = stopFilterKeymap{
+    NextMode: key.NewBinding(key.WithKeys("tab")),
+    PrevMode: key.NewBinding(key.WithKeys("shift+tab")),
+    Save: key.NewBinding(key.WithKeys("s")),
+    Platforms: key.NewBinding(key.WithKeys("p")),
+}
+
+// "all" first, then one entry per mode
+var stopModeFilters = append([]api.Mode{api.ModeAny}, api.AllModes...)
+
+// moves idx forwards or backwards through stopModeFilters, wrapping around
+func cycleModeFilter(idx int, step int) int {
+    n := len(stopModeFilters)
+    return ((idx+step)%n + n) % n
+}
+
+// stationName is set while a station's platforms are listed
+func stopSelectPrompt(filter int, stationName string) string {
+    if stationName != "" {
+        return fmt.Sprintf("Select platform at %s:\n", stationName)
+    }
+    mode := stopModeFilters[filter]
+    if mode == api.ModeAny {
+        return "Select stop:\n"
+    }
+    return fmt.Sprintf("Select stop (%s only):\n", mode)
+}
+
+// stopsFor is what a stop list shows: the platforms of station when it's
+// set, otherwise the stops matching input
+func stopsFor(root *RootModel, input string, filter int, station string) []api.StopSearchResult {
+    if station != "" {
+        return root.Client.FindPlatforms(station)
+    }
+    return root.Client.FindStopByMode(input, stopModeFilters[filter])
+}
+
+// mode icons, accessibility and the stop ID, shown under each stop's name
+func stopDescription(stop api.StopSearchResult) string {
+    parts := []string{}
+    if icons := styles.IconsForModes(stop.Modes); icons != "" {
+        parts = append(parts, icons)
+    }
+    if stop.WheelchairBoarding == api.WheelchairAccessible {
+        parts = append(parts, styles.WheelchairIcon)
+    }
+    parts = append(parts, stop.ID)
+    return strings.Join(parts, "  ")
+}
+
+// asks for a name and saves the stop to the user's favourites
+func newSaveStopState(root *RootModel, id string, name string) AppState {
+    return newPromptState(root, "Save stop as", name, func(favName string) error {
+        return root.Favourites.AddStop(state.FavouriteStop{
+            Name: favName,
+            StopID: id,
+            StopName: name,
+        })
+    })
+}
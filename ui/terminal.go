@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// escape sequences bubbletea doesn't have commands for. Each goes out at
+// the start of the next frame, and only that one, so the renderer is the
+// only thing writing to the terminal, and over SSH that's the session
+
+type terminalSeq struct {
+	seq   string
+	drawn chan struct{} // closed once the sequence is in a frame
+}
+
+// sent once the clipboard sequence has been drawn
+type clipboardCopiedMsg struct{}
+
+// sent once the reminder's notification has been drawn
+type reminderNotifiedMsg struct{}
+
+// writeTerminal queues seq for the next frame, wrapped for tmux when running
+// locally inside it, and sends done once it's been drawn
+func (m *RootModel) writeTerminal(seq string, done tea.Msg) tea.Cmd {
+	if !m.Remote && os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	drawn := make(chan struct{})
+	m.terminalSeqs = append(m.terminalSeqs, terminalSeq{seq: seq, drawn: drawn})
+
+	return func() tea.Msg {
+		<-drawn
+		return done
+	}
+}
+
+// takeTerminalSeqs hands the queued sequences to one frame, and forgets them
+// so they aren't sent again with the next
+func (m *RootModel) takeTerminalSeqs() string {
+	var b strings.Builder
+	for _, s := range m.terminalSeqs {
+		b.WriteString(s.seq)
+		close(s.drawn)
+	}
+	m.terminalSeqs = nil
+	return b.String()
+}
+
+// osc52 sets the system clipboard, https://invisible-island.net/xterm/ctlseqs/ctlseqs.html
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// copyToClipboard puts text on the user's clipboard
+func (m *RootModel) copyToClipboard(text string) tea.Cmd {
+	return m.writeTerminal(osc52(text), clipboardCopiedMsg{})
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/76creates/stickers/flexbox"
+)
+
+// testRootModel has the cells to draw frames, without a database
+func testRootModel() *RootModel {
+	m := &RootModel{flexBox: flexbox.New(40, 10), fullMap: flexbox.New(40, 10)}
+	m.flexBox.AddRows([]*flexbox.Row{m.flexBox.NewRow().AddCells(flexbox.NewCell(1, 1))})
+	m.fullMap.AddRows([]*flexbox.Row{m.fullMap.NewRow().AddCells(flexbox.NewCell(1, 1))})
+	m.Remote = true // no tmux wrapping
+	return m
+}
+
+func TestNotifyOnce(t *testing.T) {
+	m := testRootModel()
+	cmd := m.notify("trip", "Leave now; for the T1")
+
+	frames := m.View() + m.View()
+	if n := strings.Count(frames, "\x1b]9;"); n != 1 {
+		t.Errorf("expected the notification in one frame, got it %d times", n)
+	}
+	if n := strings.Count(frames, "\a"); n != 3 {
+		t.Errorf("expected the bell and two sequences once, got %d BELs", n)
+	}
+	if !strings.Contains(frames, "\x1b]777;notify;trip;Leave now, for the T1\a") {
+		t.Errorf("expected the body's ; replaced, got %q", frames)
+	}
+
+	// drawn, so the reminder hears about it
+	if _, ok := cmd().(reminderNotifiedMsg); !ok {
+		t.Error("expected reminderNotifiedMsg once drawn")
+	}
+}
+
+func TestCopyToClipboardOnce(t *testing.T) {
+	m := testRootModel()
+	m.copyToClipboard("journey")
+
+	frames := m.View() + m.View()
+	if n := strings.Count(frames, osc52("journey")); n != 1 {
+		t.Errorf("expected the clipboard write in one frame, got it %d times", n)
+	}
+}
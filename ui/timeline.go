@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/isobelmcrae/trip/api"
+	"github.com/isobelmcrae/trip/format"
+	"github.com/isobelmcrae/trip/styles"
+)
+
+// timeline characters, rides are solid and walks shaded so the two still
+// differ where colours don't come through
+const (
+	timelineRide = "█"
+	timelineWalk = "░"
+	timelineWait = "┄"
+)
+
+// span is when a leg starts and finishes
+type span struct {
+	start, end time.Time
+	leg        api.Leg
+}
+
+func legSpans(j api.Journey) []span {
+	var spans []span
+	for _, l := range j.Legs {
+		start, err := time.Parse(time.RFC3339, l.Origin.DepartureTimeEstimated)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, l.Destination.ArrivalTimeEstimated)
+		if err != nil {
+			continue
+		}
+		spans = append(spans, span{start, end, l})
+	}
+	return spans
+}
+
+// journeyTimeline draws j as one row of segments sized by how long each
+// leg takes, in its line's colour, between from and to. Waits between
+// legs are left as a faint dashed line, and time outside the journey blank
+func journeyTimeline(j api.Journey, from time.Time, to time.Time, width int) string {
+	total := to.Sub(from)
+	if width <= 0 || total <= 0 {
+		return ""
+	}
+
+	spans := legSpans(j)
+	if len(spans) == 0 {
+		return ""
+	}
+	start, end := spans[0].start, spans[len(spans)-1].end
+
+	wait := lipgloss.NewStyle().Faint(true)
+
+	var row strings.Builder
+	// runs of the same segment are rendered together, keyed by their
+	// character and line
+	var run strings.Builder
+	var runKey string
+	var runStyle lipgloss.Style
+	flush := func() {
+		if run.Len() > 0 {
+			row.WriteString(runStyle.Render(run.String()))
+			run.Reset()
+		}
+	}
+
+	for i := range width {
+		// the middle of the column
+		t := from.Add(time.Duration((float64(i) + 0.5) / float64(width) * float64(total)))
+
+		char, key, style := " ", "", lipgloss.NewStyle()
+		if !t.Before(start) && t.Before(end) {
+			char, key, style = timelineWait, "wait", wait
+			for _, sp := range spans {
+				if t.Before(sp.start) || !t.Before(sp.end) {
+					continue
+				}
+				transport := format.LegTransport(sp.leg)
+				char, key = timelineRide, transport
+				if sp.leg.IsWalk() {
+					char = timelineWalk
+				}
+				style = lipgloss.NewStyle().Foreground(styles.LgColourForLine(transport))
+				break
+			}
+		}
+
+		if key != runKey {
+			flush()
+			runKey, runStyle = key, style
+		}
+		run.WriteString(char)
+	}
+	flush()
+
+	return row.String()
+}
+
+// journeyOwnTimeline is a journey's timeline from its departure to arrival
+func journeyOwnTimeline(j api.Journey, width int) string {
+	spans := legSpans(j)
+	if len(spans) == 0 {
+		return ""
+	}
+	return journeyTimeline(j, spans[0].start, spans[len(spans)-1].end, width)
+}
+
+// timelineAxis is the earliest departure and latest arrival of journeys,
+// so their timelines can be drawn against each other
+func timelineAxis(journeys []api.Journey) (from time.Time, to time.Time) {
+	for _, j := range journeys {
+		spans := legSpans(j)
+		if len(spans) == 0 {
+			continue
+		}
+		if start := spans[0].start; from.IsZero() || start.Before(from) {
+			from = start
+		}
+		if end := spans[len(spans)-1].end; end.After(to) {
+			to = end
+		}
+	}
+	return from, to
+}